@@ -0,0 +1,405 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrEntryTooLarge 单条数据超过MaxBytes
+var ErrEntryTooLarge = errors.New("cache: entry larger than MaxBytes")
+
+// EvictionPolicy 容量不足时的淘汰策略
+type EvictionPolicy int
+
+const (
+	// LRU 淘汰最久未被访问的数据
+	LRU EvictionPolicy = iota
+
+	// LFU 淘汰访问次数最少的数据，次数相同时淘汰最久未被访问的
+	LFU
+)
+
+// EvictReason 数据被淘汰的原因
+type EvictReason int
+
+const (
+	// EvictCapacity 超出条数或字节数限制
+	EvictCapacity EvictReason = iota
+
+	// EvictExpired 已过期
+	EvictExpired
+)
+
+// BoundedOpts Bounded配置
+type BoundedOpts struct {
+	Policy     EvictionPolicy
+	MaxEntries int                                                   // 最大条数，为0时不限制
+	MaxBytes   int64                                                 // 最大字节数，按Sizer估算，为0时不限制
+	Sizer      func(key string, val interface{}) int64               // 估算单条数据的字节数，为空时使用DefaultSizer
+	OnEvict    func(key string, val interface{}, reason EvictReason) // 数据被淘汰时回调，Delete及覆盖写入不会触发
+}
+
+// BoundedStats 命中及淘汰统计
+type BoundedStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+}
+
+// Bounded 限制容量的进程内缓存，并发安全
+// 过期数据在访问时删除，也可以定期调用DeleteExpired清理
+type Bounded struct {
+	mu      sync.Mutex
+	opts    BoundedOpts
+	items   map[string]*entry
+	order   evictionOrder
+	bytes   int64
+	stats   BoundedStats
+	evicted []evicted
+}
+
+type entry struct {
+	key     string
+	val     interface{}
+	expired time.Time
+	size    int64
+	freq    int
+	elem    *list.Element
+}
+
+type evicted struct {
+	key    string
+	val    interface{}
+	reason EvictReason
+}
+
+// NewBounded 实例化
+func NewBounded(opts BoundedOpts) *Bounded {
+	if opts.Sizer == nil {
+		opts.Sizer = DefaultSizer
+	}
+	var order evictionOrder = newLRUOrder()
+	if opts.Policy == LFU {
+		order = newLFUOrder()
+	}
+	return &Bounded{
+		opts:  opts,
+		items: map[string]*entry{},
+		order: order,
+	}
+}
+
+// DefaultSizer 估算数据大小，字符串及[]byte取长度，其余取json编码后的长度
+func DefaultSizer(key string, val interface{}) int64 {
+	size := int64(len(key))
+	switch v := val.(type) {
+	case string:
+		return size + int64(len(v))
+	case []byte:
+		return size + int64(len(v))
+	}
+	data, err := json.Marshal(val)
+	if err != nil {
+		return size + 64
+	}
+	return size + int64(len(data))
+}
+
+// Get 获取一个值
+func (b *Bounded) Get(key string) interface{} {
+	val, _ := b.GetCtx(context.Background(), key)
+	return val
+}
+
+// IsExist 判断key是否存在，不计入命中统计
+func (b *Bounded) IsExist(key string) bool {
+	b.mu.Lock()
+	_, ok := b.lookup(key)
+	b.mu.Unlock()
+
+	b.notify()
+	return ok
+}
+
+// Set 设置一个值，容量不足时按策略淘汰
+func (b *Bounded) Set(key string, val interface{}, timeout time.Duration) error {
+	size := b.opts.Sizer(key, val)
+	if b.opts.MaxBytes > 0 && size > b.opts.MaxBytes {
+		return ErrEntryTooLarge
+	}
+
+	b.mu.Lock()
+	b.store(key, val, timeout, size)
+	b.mu.Unlock()
+
+	b.notify()
+	return nil
+}
+
+// Add key不存在或已过期时写入
+func (b *Bounded) Add(key string, val interface{}, timeout time.Duration) (bool, error) {
+	size := b.opts.Sizer(key, val)
+	if b.opts.MaxBytes > 0 && size > b.opts.MaxBytes {
+		return false, ErrEntryTooLarge
+	}
+
+	b.mu.Lock()
+	_, exist := b.lookup(key)
+	if !exist {
+		b.store(key, val, timeout, size)
+	}
+	b.mu.Unlock()
+
+	b.notify()
+	return !exist, nil
+}
+
+// store 写入数据，容量不足时按策略淘汰，需持有锁
+func (b *Bounded) store(key string, val interface{}, timeout time.Duration, size int64) {
+	if old, ok := b.items[key]; ok {
+		b.remove(old)
+	}
+	// 先腾出空间再写入，避免LFU下新写入的数据立即被淘汰
+	for len(b.items) > 0 && b.overCapacity(size) {
+		b.evict(b.order.victim(), EvictCapacity)
+	}
+	e := &entry{
+		key:     key,
+		val:     val,
+		expired: time.Now().Add(timeout),
+		size:    size,
+	}
+	b.items[key] = e
+	b.bytes += size
+	b.order.add(e)
+}
+
+// Delete 删除
+func (b *Bounded) Delete(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if e, ok := b.items[key]; ok {
+		b.remove(e)
+	}
+	return nil
+}
+
+// GetCtx 获取一个值，不存在时返回ErrMiss
+func (b *Bounded) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	e, ok := b.lookup(key)
+	if ok {
+		b.stats.Hits++
+		b.order.touch(e)
+	} else {
+		b.stats.Misses++
+	}
+	b.mu.Unlock()
+
+	b.notify()
+	if !ok {
+		return nil, ErrMiss
+	}
+	return e.val, nil
+}
+
+// SetCtx 设置一个值
+func (b *Bounded) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.Set(key, val, timeout)
+}
+
+// IsExistCtx 判断key是否存在
+func (b *Bounded) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return b.IsExist(key), nil
+}
+
+// DeleteCtx 删除
+func (b *Bounded) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.Delete(key)
+}
+
+// DeleteExpired 删除所有过期数据
+func (b *Bounded) DeleteExpired() {
+	now := time.Now()
+	b.mu.Lock()
+	for _, e := range b.items {
+		if e.expired.Before(now) {
+			b.evict(e, EvictExpired)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify()
+}
+
+// Stats 返回当前的统计
+func (b *Bounded) Stats() BoundedStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := b.stats
+	stats.Entries = len(b.items)
+	stats.Bytes = b.bytes
+	return stats
+}
+
+// lookup 查找未过期的数据，过期时淘汰，需持有锁
+func (b *Bounded) lookup(key string) (*entry, bool) {
+	e, ok := b.items[key]
+	if !ok {
+		return nil, false
+	}
+	if e.expired.Before(time.Now()) {
+		b.evict(e, EvictExpired)
+		return nil, false
+	}
+	return e, true
+}
+
+// overCapacity 再写入size字节的一条数据是否超出限制，需持有锁
+func (b *Bounded) overCapacity(size int64) bool {
+	return (b.opts.MaxEntries > 0 && len(b.items)+1 > b.opts.MaxEntries) ||
+		(b.opts.MaxBytes > 0 && b.bytes+size > b.opts.MaxBytes)
+}
+
+// evict 淘汰数据并记录待回调，需持有锁
+func (b *Bounded) evict(e *entry, reason EvictReason) {
+	b.remove(e)
+	if reason == EvictCapacity {
+		b.stats.Evictions++
+	}
+	if b.opts.OnEvict != nil {
+		b.evicted = append(b.evicted, evicted{key: e.key, val: e.val, reason: reason})
+	}
+}
+
+// remove 删除数据，需持有锁
+func (b *Bounded) remove(e *entry) {
+	delete(b.items, e.key)
+	b.bytes -= e.size
+	b.order.remove(e)
+}
+
+// notify 在锁外执行淘汰回调，回调中可以安全地访问缓存
+func (b *Bounded) notify() {
+	if b.opts.OnEvict == nil {
+		return
+	}
+	b.mu.Lock()
+	pending := b.evicted
+	b.evicted = nil
+	b.mu.Unlock()
+
+	for _, v := range pending {
+		b.opts.OnEvict(v.key, v.val, v.reason)
+	}
+}
+
+// evictionOrder 维护淘汰顺序
+type evictionOrder interface {
+	add(e *entry)
+	touch(e *entry)
+	remove(e *entry)
+	victim() *entry
+}
+
+// lruOrder 链表头部为最近访问
+type lruOrder struct {
+	l *list.List
+}
+
+func newLRUOrder() *lruOrder {
+	return &lruOrder{l: list.New()}
+}
+
+func (o *lruOrder) add(e *entry) {
+	e.elem = o.l.PushFront(e)
+}
+
+func (o *lruOrder) touch(e *entry) {
+	o.l.MoveToFront(e.elem)
+}
+
+func (o *lruOrder) remove(e *entry) {
+	o.l.Remove(e.elem)
+}
+
+func (o *lruOrder) victim() *entry {
+	return o.l.Back().Value.(*entry)
+}
+
+// lfuOrder 按访问次数分桶，桶内按LRU排序
+type lfuOrder struct {
+	buckets map[int]*list.List
+	minFreq int
+}
+
+func newLFUOrder() *lfuOrder {
+	return &lfuOrder{buckets: map[int]*list.List{}}
+}
+
+func (o *lfuOrder) add(e *entry) {
+	e.freq = 1
+	o.push(e)
+	o.minFreq = 1
+}
+
+func (o *lfuOrder) touch(e *entry) {
+	o.remove(e)
+	e.freq++
+	o.push(e)
+}
+
+func (o *lfuOrder) remove(e *entry) {
+	bucket := o.buckets[e.freq]
+	bucket.Remove(e.elem)
+	if bucket.Len() == 0 {
+		delete(o.buckets, e.freq)
+		if o.minFreq == e.freq {
+			o.minFreq++
+		}
+	}
+}
+
+func (o *lfuOrder) victim() *entry {
+	bucket, ok := o.buckets[o.minFreq]
+	if !ok {
+		// 删除数据后minFreq可能已失效，重新查找
+		o.minFreq = 0
+		for freq := range o.buckets {
+			if o.minFreq == 0 || freq < o.minFreq {
+				o.minFreq = freq
+			}
+		}
+		bucket = o.buckets[o.minFreq]
+	}
+	return bucket.Back().Value.(*entry)
+}
+
+func (o *lfuOrder) push(e *entry) {
+	bucket, ok := o.buckets[e.freq]
+	if !ok {
+		bucket = list.New()
+		o.buckets[e.freq] = bucket
+	}
+	e.elem = bucket.PushFront(e)
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Bounded 实现Cache接口
+var _ Cache = (*Bounded)(nil)
+
+func TestBoundedLRU(t *testing.T) {
+	var evictedKeys []string
+	b := NewBounded(BoundedOpts{
+		Policy:     LRU,
+		MaxEntries: 2,
+		OnEvict: func(key string, val interface{}, reason EvictReason) {
+			assert.Equal(t, EvictCapacity, reason)
+			evictedKeys = append(evictedKeys, key)
+		},
+	})
+	assert.Nil(t, b.Set("a", 1, time.Minute))
+	assert.Nil(t, b.Set("b", 2, time.Minute))
+	assert.Equal(t, 1, b.Get("a"))
+	assert.Nil(t, b.Set("c", 3, time.Minute))
+
+	assert.Equal(t, []string{"b"}, evictedKeys)
+	assert.False(t, b.IsExist("b"))
+	assert.True(t, b.IsExist("a"))
+	assert.True(t, b.IsExist("c"))
+
+	// 覆盖写入不触发淘汰
+	assert.Nil(t, b.Set("c", 4, time.Minute))
+	assert.Equal(t, 4, b.Get("c"))
+	assert.Equal(t, []string{"b"}, evictedKeys)
+
+	assert.Nil(t, b.Get("b"))
+	assert.Equal(t, BoundedStats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2, Bytes: b.Stats().Bytes}, b.Stats())
+}
+
+func TestBoundedLFU(t *testing.T) {
+	b := NewBounded(BoundedOpts{Policy: LFU, MaxEntries: 3})
+	assert.Nil(t, b.Set("a", 1, time.Minute))
+	assert.Nil(t, b.Set("b", 2, time.Minute))
+	assert.Nil(t, b.Set("c", 3, time.Minute))
+	for i := 0; i < 3; i++ {
+		b.Get("a")
+	}
+	b.Get("b")
+	b.Get("c")
+
+	// b、c访问次数相同，淘汰更久未访问的b
+	assert.Nil(t, b.Set("d", 4, time.Minute))
+	assert.False(t, b.IsExist("b"))
+	// 新写入的d访问次数最少，下一次被淘汰
+	assert.Nil(t, b.Set("e", 5, time.Minute))
+	assert.False(t, b.IsExist("d"))
+	assert.True(t, b.IsExist("a"))
+	assert.True(t, b.IsExist("c"))
+	assert.True(t, b.IsExist("e"))
+
+	// 删除最少访问的数据后仍能找到正确的淘汰对象
+	assert.Nil(t, b.Delete("e"))
+	assert.Nil(t, b.Set("f", 6, time.Minute))
+	b.Get("f")
+	b.Get("f")
+	assert.Nil(t, b.Set("g", 7, time.Minute))
+	assert.False(t, b.IsExist("c"))
+	assert.Equal(t, uint64(3), b.Stats().Evictions)
+}
+
+func TestBoundedMaxBytes(t *testing.T) {
+	var evictedKeys []string
+	b := NewBounded(BoundedOpts{
+		MaxBytes: 10,
+		OnEvict: func(key string, val interface{}, reason EvictReason) {
+			evictedKeys = append(evictedKeys, key)
+		},
+	})
+	assert.Nil(t, b.Set("a", "1234", time.Minute))
+	assert.Nil(t, b.Set("b", "1234", time.Minute))
+	assert.Equal(t, int64(10), b.Stats().Bytes)
+	assert.Nil(t, b.Set("c", "12", time.Minute))
+	assert.Equal(t, []string{"a"}, evictedKeys)
+	assert.Equal(t, int64(8), b.Stats().Bytes)
+
+	assert.Equal(t, ErrEntryTooLarge, b.Set("d", "12345678901", time.Minute))
+	assert.False(t, b.IsExist("d"))
+
+	// 默认按json长度估算
+	assert.Equal(t, int64(len("k")+len(`{"a":1}`)), DefaultSizer("k", map[string]int{"a": 1}))
+}
+
+func TestBoundedExpired(t *testing.T) {
+	var reasons []EvictReason
+	b := NewBounded(BoundedOpts{
+		OnEvict: func(key string, val interface{}, reason EvictReason) {
+			reasons = append(reasons, reason)
+		},
+	})
+	assert.Nil(t, b.Set("a", 1, -time.Second))
+	assert.Nil(t, b.Set("b", 1, -time.Second))
+	assert.Nil(t, b.Set("c", 1, time.Minute))
+
+	assert.Nil(t, b.Get("a"))
+	b.DeleteExpired()
+	assert.Equal(t, []EvictReason{EvictExpired, EvictExpired}, reasons)
+	stats := b.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(0), stats.Evictions)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestBoundedAdd(t *testing.T) {
+	b := NewBounded(BoundedOpts{MaxEntries: 2})
+	ok, err := b.Add("a", 1, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = b.Add("a", 2, time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, b.Get("a"))
+
+	assert.Nil(t, b.Set("b", 1, -time.Second))
+	ok, err = b.Add("b", 2, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, b.Get("b"))
+
+	_, err = NewBounded(BoundedOpts{MaxBytes: 1}).Add("large", "value", time.Minute)
+	assert.Equal(t, ErrEntryTooLarge, err)
+}
+
+func TestBoundedCallbackReentrant(t *testing.T) {
+	var b *Bounded
+	b = NewBounded(BoundedOpts{
+		MaxEntries: 1,
+		OnEvict: func(key string, val interface{}, reason EvictReason) {
+			// 回调在锁外执行，可以访问缓存
+			b.IsExist(key)
+		},
+	})
+	assert.Nil(t, b.Set("a", 1, time.Minute))
+	assert.Nil(t, b.Set("b", 1, time.Minute))
+}
+
+func TestBoundedConcurrent(t *testing.T) {
+	for _, policy := range []EvictionPolicy{LRU, LFU} {
+		b := NewBounded(BoundedOpts{Policy: policy, MaxEntries: 5, OnEvict: func(string, interface{}, EvictReason) {}})
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 200; j++ {
+					key := fmt.Sprintf("key_%d", (i+j)%10)
+					_ = b.Set(key, j, time.Duration(j%3)*time.Millisecond)
+					b.Get(key)
+					if j%5 == 0 {
+						_ = b.Delete(key)
+					}
+					if j%50 == 0 {
+						b.DeleteExpired()
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.LessOrEqual(t, b.Stats().Entries, 5)
+	}
+}
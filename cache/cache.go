@@ -1,6 +1,13 @@
 package cache
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss key不存在或已过期
+var ErrMiss = errors.New("cache: miss")
 
 // Cache interface
 type Cache interface {
@@ -9,3 +16,96 @@ type Cache interface {
 	IsExist(key string) bool
 	Delete(key string) error
 }
+
+// Adder 可选接口，key不存在时才写入，判断与写入是原子的
+// 返回false表示key已存在，多实例部署时可用于请求去重
+type Adder interface {
+	Add(key string, val interface{}, timeout time.Duration) (bool, error)
+}
+
+// ContextCache 可取消的缓存接口，区分未命中与连接错误
+// GetCtx未命中时返回ErrMiss，其余错误表示缓存不可用
+type ContextCache interface {
+	GetCtx(ctx context.Context, key string) (interface{}, error)
+	SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error
+	IsExistCtx(ctx context.Context, key string) (bool, error)
+	DeleteCtx(ctx context.Context, key string) error
+}
+
+// NewContextCache 将Cache适配为ContextCache，已实现ContextCache时直接返回
+// 适配后的GetCtx无法区分未命中与错误，均返回ErrMiss
+func NewContextCache(c Cache) ContextCache {
+	if cc, ok := c.(ContextCache); ok {
+		return cc
+	}
+	return contextCache{c}
+}
+
+// NewLegacyCache 将ContextCache适配为Cache，已实现Cache时直接返回
+// 适配后的Get、IsExist在出错时分别返回nil、false
+func NewLegacyCache(c ContextCache) Cache {
+	if legacy, ok := c.(Cache); ok {
+		return legacy
+	}
+	return legacyCache{c}
+}
+
+type contextCache struct {
+	c Cache
+}
+
+func (a contextCache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if val := a.c.Get(key); val != nil {
+		return val, nil
+	}
+	return nil, ErrMiss
+}
+
+func (a contextCache) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.c.Set(key, val, timeout)
+}
+
+func (a contextCache) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return a.c.IsExist(key), nil
+}
+
+func (a contextCache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.c.Delete(key)
+}
+
+type legacyCache struct {
+	c ContextCache
+}
+
+func (a legacyCache) Get(key string) interface{} {
+	val, err := a.c.GetCtx(context.Background(), key)
+	if err != nil {
+		return nil
+	}
+	return val
+}
+
+func (a legacyCache) Set(key string, val interface{}, timeout time.Duration) error {
+	return a.c.SetCtx(context.Background(), key, val, timeout)
+}
+
+func (a legacyCache) IsExist(key string) bool {
+	ok, err := a.c.IsExistCtx(context.Background(), key)
+	return ok && err == nil
+}
+
+func (a legacyCache) Delete(key string) error {
+	return a.c.DeleteCtx(context.Background(), key)
+}
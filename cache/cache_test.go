@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ ContextCache = (*Redis)(nil)
+	_ ContextCache = (*Memcache)(nil)
+	_ ContextCache = (*Memory)(nil)
+	_ ContextCache = (*Bounded)(nil)
+
+	_ Adder = (*Redis)(nil)
+	_ Adder = (*Memcache)(nil)
+	_ Adder = (*Memory)(nil)
+	_ Adder = (*Bounded)(nil)
+)
+
+// legacyOnly 只实现Cache的第三方缓存
+type legacyOnly struct {
+	c Cache
+}
+
+func (l legacyOnly) Get(key string) interface{} {
+	return l.c.Get(key)
+}
+
+func (l legacyOnly) Set(key string, val interface{}, timeout time.Duration) error {
+	return l.c.Set(key, val, timeout)
+}
+
+func (l legacyOnly) IsExist(key string) bool {
+	return l.c.IsExist(key)
+}
+
+func (l legacyOnly) Delete(key string) error {
+	return l.c.Delete(key)
+}
+
+// contextOnly 只实现ContextCache的第三方缓存
+type contextOnly struct {
+	mem *Memory
+	err error
+}
+
+func (c contextOnly) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.mem.GetCtx(ctx, key)
+}
+
+func (c contextOnly) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	return c.mem.SetCtx(ctx, key, val, timeout)
+}
+
+func (c contextOnly) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.mem.IsExistCtx(ctx, key)
+}
+
+func (c contextOnly) DeleteCtx(ctx context.Context, key string) error {
+	return c.mem.DeleteCtx(ctx, key)
+}
+
+func TestContextCache(t *testing.T) {
+	ctx := context.Background()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	for name, c := range map[string]ContextCache{
+		"memory":  NewMemoryWithCleanup(0),
+		"bounded": NewBounded(BoundedOpts{MaxEntries: 10}),
+		"adapter": NewContextCache(legacyOnly{NewMemoryWithCleanup(0)}),
+	} {
+		_, err := c.GetCtx(ctx, "missing")
+		assert.ErrorIs(t, err, ErrMiss, name)
+
+		assert.Nil(t, c.SetCtx(ctx, "key", "value", time.Minute), name)
+		val, err := c.GetCtx(ctx, "key")
+		assert.Nil(t, err, name)
+		assert.Equal(t, "value", val, name)
+		ok, err := c.IsExistCtx(ctx, "key")
+		assert.True(t, ok, name)
+		assert.Nil(t, err, name)
+
+		_, err = c.GetCtx(canceled, "key")
+		assert.ErrorIs(t, err, context.Canceled, name)
+		assert.ErrorIs(t, c.SetCtx(canceled, "key", "value", time.Minute), context.Canceled, name)
+		assert.ErrorIs(t, c.DeleteCtx(canceled, "key"), context.Canceled, name)
+		_, err = c.IsExistCtx(canceled, "key")
+		assert.ErrorIs(t, err, context.Canceled, name)
+
+		assert.Nil(t, c.DeleteCtx(ctx, "key"), name)
+		_, err = c.GetCtx(ctx, "key")
+		assert.ErrorIs(t, err, ErrMiss, name)
+	}
+}
+
+func TestCacheAdapters(t *testing.T) {
+	mem := NewMemoryWithCleanup(0)
+	assert.Same(t, mem, NewContextCache(mem))
+	assert.Same(t, mem, NewLegacyCache(mem))
+
+	legacy := NewLegacyCache(contextOnly{mem: NewMemoryWithCleanup(0)})
+	assert.Nil(t, legacy.Get("missing"))
+	assert.Nil(t, legacy.Set("key", "value", time.Minute))
+	assert.Equal(t, "value", legacy.Get("key"))
+	assert.True(t, legacy.IsExist("key"))
+	assert.Nil(t, legacy.Delete("key"))
+	assert.False(t, legacy.IsExist("key"))
+
+	// 出错时按旧接口的约定返回nil、false
+	broken := NewLegacyCache(contextOnly{mem: NewMemoryWithCleanup(0), err: errors.New("down")})
+	assert.Nil(t, broken.Get("key"))
+	assert.False(t, broken.IsExist("key"))
+}
+
+func TestUnavailableBackends(t *testing.T) {
+	ctx := context.Background()
+
+	r := NewRedis(&RedisOpts{Host: "127.0.0.1:1"})
+	assert.NotPanics(t, func() {
+		assert.False(t, r.IsExist("key"))
+	})
+	assert.Nil(t, r.Get("key"))
+	_, err := r.GetCtx(ctx, "key")
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrMiss))
+	_, err = r.IsExistCtx(ctx, "key")
+	assert.NotNil(t, err)
+	assert.NotNil(t, r.DeleteCtx(ctx, "key"))
+
+	m := NewMemcache("127.0.0.1:1")
+	_, err = m.GetCtx(ctx, "key")
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrMiss))
+	_, err = m.IsExistCtx(ctx, "key")
+	assert.NotNil(t, err)
+}
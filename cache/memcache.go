@@ -1,7 +1,9 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -32,6 +34,23 @@ func (mem *Memcache) Get(key string) interface{} {
 	return result
 }
 
+// GetBytes 获取原始数据
+func (mem *Memcache) GetBytes(key string) ([]byte, bool, error) {
+	item, err := mem.conn.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return item.Value, true, nil
+}
+
+// SetBytes 设置原始数据
+func (mem *Memcache) SetBytes(key string, data []byte, timeout time.Duration) error {
+	return mem.conn.Set(&memcache.Item{Key: key, Value: data, Expiration: int32(timeout / time.Second)})
+}
+
 // IsExist check value exists in memcache.
 func (mem *Memcache) IsExist(key string) bool {
 	if _, err := mem.conn.Get(key); err != nil {
@@ -51,7 +70,67 @@ func (mem *Memcache) Set(key string, val interface{}, timeout time.Duration) (er
 	return mem.conn.Set(item)
 }
 
+// Add key不存在时写入，由memcache的add命令保证原子性
+func (mem *Memcache) Add(key string, val interface{}, timeout time.Duration) (bool, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return false, err
+	}
+	err = mem.conn.Add(&memcache.Item{Key: key, Value: data, Expiration: int32(timeout / time.Second)})
+	if errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 // Delete delete value in memcache.
 func (mem *Memcache) Delete(key string) error {
 	return mem.conn.Delete(key)
 }
+
+// GetCtx 获取一个值，不存在时返回ErrMiss，memcache客户端不支持取消，仅在请求前检查ctx
+func (mem *Memcache) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, ok, err := mem.GetBytes(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMiss
+	}
+	var result interface{}
+	if err = json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetCtx 设置一个值
+func (mem *Memcache) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mem.Set(key, val, timeout)
+}
+
+// IsExistCtx 判断key是否存在
+func (mem *Memcache) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	_, ok, err := mem.GetBytes(key)
+	return ok, err
+}
+
+// DeleteCtx 删除，key不存在时不返回错误
+func (mem *Memcache) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := mem.conn.Delete(key); err != nil && err != memcache.ErrCacheMiss {
+		return err
+	}
+	return nil
+}
@@ -1,15 +1,25 @@
 package cache
 
 import (
+	"context"
+	"runtime"
 	"sync"
 	"time"
 )
 
-// Memory struct contains *memcache.Client
+// DefaultCleanupInterval NewMemory清理过期数据的默认间隔
+const DefaultCleanupInterval = time.Minute
+
+// Memory 进程内缓存，并发安全，后台定期清理过期数据
 type Memory struct {
-	sync.Mutex
+	*memory
+}
 
-	data map[string]*data
+// memory Memory的实际数据，清理协程只引用memory，Memory不再被引用时可以被回收并停止清理
+type memory struct {
+	mu      sync.RWMutex
+	data    map[string]*data
+	janitor *janitor
 }
 
 type data struct {
@@ -17,41 +27,63 @@ type data struct {
 	Expired time.Time
 }
 
-// NewMemory create new memcache
+// expired 是否已过期
+func (d *data) expired(now time.Time) bool {
+	return d.Expired.Before(now)
+}
+
+// NewMemory create new memory cache，每DefaultCleanupInterval清理一次过期数据
 func NewMemory() *Memory {
-	return &Memory{
+	return NewMemoryWithCleanup(DefaultCleanupInterval)
+}
+
+// NewMemoryWithCleanup 指定清理过期数据的间隔，interval小于等于0时不启动后台清理
+func NewMemoryWithCleanup(interval time.Duration) *Memory {
+	m := &memory{
 		data: map[string]*data{},
 	}
+	mem := &Memory{m}
+	if interval > 0 {
+		m.janitor = newJanitor(interval)
+		go m.janitor.run(m)
+		// 未调用Close时，Memory被回收后停止清理协程，避免泄漏
+		runtime.SetFinalizer(mem, (*Memory).Close)
+	}
+	return mem
 }
 
 // Get return cached value
-func (mem *Memory) Get(key string) interface{} {
-	if ret, ok := mem.data[key]; ok {
-		if ret.Expired.Before(time.Now()) {
-			mem.deleteKey(key)
-			return nil
-		}
-		return ret.Data
-	}
-	return nil
+func (mem *memory) Get(key string) interface{} {
+	val, _ := mem.lookup(key)
+	return val
 }
 
-// IsExist check value exists in memcache.
-func (mem *Memory) IsExist(key string) bool {
-	if ret, ok := mem.data[key]; ok {
-		if ret.Expired.Before(time.Now()) {
-			mem.deleteKey(key)
-			return false
-		}
-		return true
+// IsExist check value exists in memory.
+func (mem *memory) IsExist(key string) bool {
+	_, ok := mem.lookup(key)
+	return ok
+}
+
+// lookup 查找未过期的值，过期时顺便删除
+func (mem *memory) lookup(key string) (interface{}, bool) {
+	mem.mu.RLock()
+	ret, ok := mem.data[key]
+	mem.mu.RUnlock()
+
+	if !ok {
+		return nil, false
 	}
-	return false
+	if ret.expired(time.Now()) {
+		mem.deleteExpired(key)
+		return nil, false
+	}
+	return ret.Data, true
 }
 
 // Set cached value with key and expire time.
-func (mem *Memory) Set(key string, val interface{}, timeout time.Duration) (err error) {
-	mem.Lock()
-	defer mem.Unlock()
+func (mem *memory) Set(key string, val interface{}, timeout time.Duration) (err error) {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
 
 	mem.data[key] = &data{
 		Data:    val,
@@ -60,15 +92,134 @@ func (mem *Memory) Set(key string, val interface{}, timeout time.Duration) (err
 	return nil
 }
 
-// Delete delete value in memcache.
-func (mem *Memory) Delete(key string) error {
-	mem.deleteKey(key)
-	return nil
+// Add key不存在或已过期时写入
+func (mem *memory) Add(key string, val interface{}, timeout time.Duration) (bool, error) {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	now := time.Now()
+	if ret, ok := mem.data[key]; ok && !ret.expired(now) {
+		return false, nil
+	}
+	mem.data[key] = &data{
+		Data:    val,
+		Expired: now.Add(timeout),
+	}
+	return true, nil
 }
 
-// deleteKey
-func (mem *Memory) deleteKey(key string) {
-	mem.Lock()
-	defer mem.Unlock()
+// Delete delete value in memory.
+func (mem *memory) Delete(key string) error {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
 	delete(mem.data, key)
+	return nil
+}
+
+// GetCtx 获取一个值，不存在时返回ErrMiss
+func (mem *memory) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	val, ok := mem.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return val, nil
+}
+
+// SetCtx 设置一个值
+func (mem *memory) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mem.Set(key, val, timeout)
+}
+
+// IsExistCtx 判断key是否存在
+func (mem *memory) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return mem.IsExist(key), nil
+}
+
+// DeleteCtx 删除
+func (mem *memory) DeleteCtx(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mem.Delete(key)
+}
+
+// DeleteExpired 删除所有过期数据，后台清理协程会定期调用
+func (mem *memory) DeleteExpired() {
+	now := time.Now()
+
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+	for key, ret := range mem.data {
+		if ret.expired(now) {
+			delete(mem.data, key)
+		}
+	}
+}
+
+// Len 缓存的数据条数，包含尚未清理的过期数据
+func (mem *memory) Len() int {
+	mem.mu.RLock()
+	defer mem.mu.RUnlock()
+	return len(mem.data)
+}
+
+// Close 停止后台清理协程，可重复调用，关闭后仍可继续读写
+func (mem *memory) Close() error {
+	if mem.janitor != nil {
+		mem.janitor.stop()
+	}
+	return nil
+}
+
+// deleteExpired 加写锁后再次确认过期，避免删除期间被重新Set的值
+func (mem *memory) deleteExpired(key string) {
+	mem.mu.Lock()
+	defer mem.mu.Unlock()
+
+	if ret, ok := mem.data[key]; ok && ret.expired(time.Now()) {
+		delete(mem.data, key)
+	}
+}
+
+// janitor 定期清理过期数据
+type janitor struct {
+	interval time.Duration
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newJanitor(interval time.Duration) *janitor {
+	return &janitor{
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+func (j *janitor) run(mem *memory) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			mem.DeleteExpired()
+		case <-j.done:
+			return
+		}
+	}
+}
+
+func (j *janitor) stop() {
+	j.once.Do(func() {
+		close(j.done)
+	})
 }
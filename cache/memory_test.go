@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+	mem := NewMemory()
+	defer mem.Close()
+
+	assert.Nil(t, mem.Set("username", "silenceper", time.Minute))
+	assert.True(t, mem.IsExist("username"))
+	assert.Equal(t, "silenceper", mem.Get("username"))
+
+	// 保留值的原始类型
+	assert.Nil(t, mem.Set("count", int64(1), time.Minute))
+	assert.Equal(t, int64(1), mem.Get("count"))
+
+	assert.Nil(t, mem.Delete("username"))
+	assert.False(t, mem.IsExist("username"))
+	assert.Nil(t, mem.Get("username"))
+}
+
+func TestMemoryAdd(t *testing.T) {
+	mem := NewMemoryWithCleanup(0)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var added int
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := mem.Add("nonce", i, time.Minute)
+			assert.Nil(t, err)
+			if ok {
+				mu.Lock()
+				added++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, added)
+
+	// 已过期的key可以重新写入
+	assert.Nil(t, mem.Set("expired", "old", -time.Second))
+	ok, err := mem.Add("expired", "new", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "new", mem.Get("expired"))
+}
+
+func TestMemoryExpired(t *testing.T) {
+	mem := NewMemoryWithCleanup(0)
+	assert.Nil(t, mem.Set("expired", "v", -time.Second))
+	assert.Nil(t, mem.Set("alive", "v", time.Minute))
+	assert.Equal(t, 2, mem.Len())
+
+	assert.False(t, mem.IsExist("expired"))
+	assert.Nil(t, mem.Get("expired"))
+	assert.Equal(t, 1, mem.Len())
+
+	assert.Nil(t, mem.Set("expired", "v", -time.Second))
+	mem.DeleteExpired()
+	assert.Equal(t, 1, mem.Len())
+	assert.True(t, mem.IsExist("alive"))
+}
+
+func TestMemoryJanitor(t *testing.T) {
+	mem := NewMemoryWithCleanup(10 * time.Millisecond)
+	assert.Nil(t, mem.Set("expired", "v", time.Millisecond))
+	assert.Nil(t, mem.Set("alive", "v", time.Minute))
+	assert.Eventually(t, func() bool {
+		return mem.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Nil(t, mem.Close())
+	assert.Nil(t, mem.Close())
+	// 关闭后仍可读写，只是不再后台清理
+	assert.Nil(t, mem.Set("expired", "v", time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 2, mem.Len())
+}
+
+func TestMemoryJanitorStopsWhenCollected(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		_ = NewMemoryWithCleanup(time.Millisecond)
+	}
+	// assert.Eventually会启动额外的协程，这里手动轮询
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestMemoryConcurrent(t *testing.T) {
+	mem := NewMemoryWithCleanup(time.Millisecond)
+	defer mem.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("key_%d", j%10)
+				_ = mem.Set(key, i, time.Duration(j%3)*time.Millisecond)
+				mem.Get(key)
+				mem.IsExist(key)
+				if j%7 == 0 {
+					_ = mem.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
@@ -51,6 +52,11 @@ func (r *Redis) SetRedisPool(pool *redis.Pool) {
 	r.conn = pool
 }
 
+// GetRedisPool 获取redis连接池
+func (r *Redis) GetRedisPool() *redis.Pool {
+	return r.conn
+}
+
 // SetConn 设置conn
 func (r *Redis) SetConn(conn *redis.Pool) {
 	r.conn = conn
@@ -58,55 +64,131 @@ func (r *Redis) SetConn(conn *redis.Pool) {
 
 // Get 获取一个值
 func (r *Redis) Get(key string) interface{} {
-	conn := r.conn.Get()
+	val, _ := r.GetCtx(context.Background(), key)
+	return val
+}
+
+// GetCtx 获取一个值，不存在时返回ErrMiss
+func (r *Redis) GetCtx(ctx context.Context, key string) (interface{}, error) {
+	conn, err := r.conn.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 	defer conn.Close()
 
-	var data []byte
-	var err error
-	if data, err = redis.Bytes(conn.Do("GET", key)); err != nil {
-		return nil
+	data, err := redis.Bytes(redis.DoContext(conn, ctx, "GET", key))
+	if err == redis.ErrNil {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
 	}
 	var reply interface{}
 	if err = json.Unmarshal(data, &reply); err != nil {
-		return nil
+		return nil, err
 	}
-
-	return reply
+	return reply, nil
 }
 
 // Set 设置一个值
-func (r *Redis) Set(key string, val interface{}, timeout time.Duration) (err error) {
+func (r *Redis) Set(key string, val interface{}, timeout time.Duration) error {
+	return r.SetCtx(context.Background(), key, val, timeout)
+}
+
+// SetCtx 设置一个值
+func (r *Redis) SetCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	conn, err := r.conn.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = redis.DoContext(conn, ctx, "SETEX", key, int64(timeout/time.Second), data)
+	return err
+}
+
+// Add key不存在时写入，使用SET NX保证多实例间的原子性
+func (r *Redis) Add(key string, val interface{}, timeout time.Duration) (bool, error) {
+	return r.AddCtx(context.Background(), key, val, timeout)
+}
+
+// AddCtx key不存在时写入
+func (r *Redis) AddCtx(ctx context.Context, key string, val interface{}, timeout time.Duration) (bool, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return false, err
+	}
+	conn, err := r.conn.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	reply, err := redis.DoContext(conn, ctx, "SET", key, data, "EX", int64(timeout/time.Second), "NX")
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// GetBytes 获取原始数据
+func (r *Redis) GetBytes(key string) ([]byte, bool, error) {
 	conn := r.conn.Get()
 	defer conn.Close()
 
-	var data []byte
-	if data, err = json.Marshal(val); err != nil {
-		return
+	data, err := redis.Bytes(conn.Do("GET", key))
+	if err == redis.ErrNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
 	}
+	return data, true, nil
+}
 
-	_, err = conn.Do("SETEX", key, int64(timeout/time.Second), data)
+// SetBytes 设置原始数据
+func (r *Redis) SetBytes(key string, data []byte, timeout time.Duration) error {
+	conn := r.conn.Get()
+	defer conn.Close()
 
-	return
+	_, err := conn.Do("SETEX", key, int64(timeout/time.Second), data)
+	return err
 }
 
-// IsExist 判断key是否存在
+// IsExist 判断key是否存在，redis不可用时返回false
 func (r *Redis) IsExist(key string) bool {
-	conn := r.conn.Get()
+	ok, _ := r.IsExistCtx(context.Background(), key)
+	return ok
+}
+
+// IsExistCtx 判断key是否存在
+func (r *Redis) IsExistCtx(ctx context.Context, key string) (bool, error) {
+	conn, err := r.conn.GetContext(ctx)
+	if err != nil {
+		return false, err
+	}
 	defer conn.Close()
 
-	a, _ := conn.Do("EXISTS", key)
-	i := a.(int64)
-	return i > 0
+	return redis.Bool(redis.DoContext(conn, ctx, "EXISTS", key))
 }
 
 // Delete 删除
 func (r *Redis) Delete(key string) error {
-	conn := r.conn.Get()
-	defer conn.Close()
+	return r.DeleteCtx(context.Background(), key)
+}
 
-	if _, err := conn.Do("DEL", key); err != nil {
+// DeleteCtx 删除
+func (r *Redis) DeleteCtx(ctx context.Context, key string) error {
+	conn, err := r.conn.GetContext(ctx)
+	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
-	return nil
+	_, err = redis.DoContext(conn, ctx, "DEL", key)
+	return err
 }
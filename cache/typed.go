@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ByteStore 可以直接存取字节数据的缓存
+// Redis、Memcache实现该接口，typed读写时不会再经过Set、Get内部的json编码
+type ByteStore interface {
+	GetBytes(key string) (data []byte, ok bool, err error)
+	SetBytes(key string, data []byte, timeout time.Duration) error
+}
+
+// SetTyped 以codec编码后写入，codec为空时使用JSONCodec
+// 不支持ByteStore的缓存(如Memory)中保存的是编码后的[]byte，保证各实现读出的结果一致
+func SetTyped(c Cache, codec Codec, key string, val interface{}, timeout time.Duration) error {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	data, err := codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	if store, ok := c.(ByteStore); ok {
+		return store.SetBytes(key, data, timeout)
+	}
+	return c.Set(key, data, timeout)
+}
+
+// GetInto 读取SetTyped写入的值并解码到dst，dst须为指针，不存在时返回false
+func GetInto(c Cache, codec Codec, key string, dst interface{}) (bool, error) {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	if store, ok := c.(ByteStore); ok {
+		data, ok, err := store.GetBytes(key)
+		if !ok || err != nil {
+			return false, err
+		}
+		return true, codec.Unmarshal(data, dst)
+	}
+
+	val := c.Get(key)
+	if val == nil {
+		return false, nil
+	}
+	data, ok := val.([]byte)
+	if !ok {
+		return false, errors.New("cache: value was not written by SetTyped")
+	}
+	return true, codec.Unmarshal(data, dst)
+}
+
+// Typed 以固定的类型及编码读写缓存
+type Typed[T any] struct {
+	cache Cache
+	codec Codec
+}
+
+// NewTyped 实例化，codec为空时使用JSONCodec
+func NewTyped[T any](c Cache, codec Codec) *Typed[T] {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	return &Typed[T]{cache: c, codec: codec}
+}
+
+// Get 获取一个值，不存在时ok为false
+func (t *Typed[T]) Get(key string) (val T, ok bool, err error) {
+	ok, err = GetInto(t.cache, t.codec, key, &val)
+	return
+}
+
+// Set 设置一个值
+func (t *Typed[T]) Set(key string, val T, timeout time.Duration) error {
+	return SetTyped(t.cache, t.codec, key, val, timeout)
+}
+
+// Delete 删除
+func (t *Typed[T]) Delete(key string) error {
+	return t.cache.Delete(key)
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedValue struct {
+	Name    string
+	Count   int64
+	Amount  float64
+	Tags    []string
+	Periods map[string]int
+	Time    time.Time
+}
+
+// byteStore 模拟Redis、Memcache的ByteStore
+type byteStore struct {
+	*Memory
+}
+
+func (s byteStore) GetBytes(key string) ([]byte, bool, error) {
+	val := s.Get(key)
+	if val == nil {
+		return nil, false, nil
+	}
+	return val.([]byte), true, nil
+}
+
+func (s byteStore) SetBytes(key string, data []byte, timeout time.Duration) error {
+	return s.Set(key, data, timeout)
+}
+
+func newTypedValue() typedValue {
+	return typedValue{
+		Name:    "客户",
+		Count:   1<<53 + 1,
+		Amount:  12.5,
+		Tags:    []string{"vat"},
+		Periods: map[string]int{"202205": 1},
+		Time:    time.Date(2022, 5, 1, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestTyped(t *testing.T) {
+	caches := map[string]Cache{
+		"memory":    NewMemoryWithCleanup(0),
+		"bounded":   NewBounded(BoundedOpts{MaxEntries: 10}),
+		"bytestore": byteStore{NewMemoryWithCleanup(0)},
+	}
+	codecs := map[string]Codec{
+		"json":    JSONCodec,
+		"gob":     GobCodec,
+		"msgpack": MsgpackCodec,
+	}
+	want := newTypedValue()
+	for cacheName, c := range caches {
+		for codecName, codec := range codecs {
+			name := cacheName + "/" + codecName
+			typed := NewTyped[typedValue](c, codec)
+
+			_, ok, err := typed.Get("missing")
+			assert.False(t, ok, name)
+			assert.Nil(t, err, name)
+
+			assert.Nil(t, typed.Set("value", want, time.Minute), name)
+			got, ok, err := typed.Get("value")
+			assert.True(t, ok, name)
+			assert.Nil(t, err, name)
+			assert.Equal(t, want.Count, got.Count, name)
+			assert.True(t, want.Time.Equal(got.Time), name)
+			got.Time = want.Time
+			assert.Equal(t, want, got, name)
+
+			assert.Nil(t, typed.Delete("value"), name)
+			_, ok, _ = typed.Get("value")
+			assert.False(t, ok, name)
+		}
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	mem := NewMemoryWithCleanup(0)
+	assert.Nil(t, SetTyped(mem, nil, "count", int64(1<<53+1), time.Minute))
+	var count int64
+	ok, err := GetInto(mem, nil, "count", &count)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1<<53+1), count)
+
+	// 通过Set直接写入的值无法解码
+	assert.Nil(t, mem.Set("raw", 1, time.Minute))
+	_, err = GetInto(mem, nil, "raw", &count)
+	assert.NotNil(t, err)
+
+	// 类型不匹配时返回解码错误
+	assert.Nil(t, SetTyped(mem, JSONCodec, "name", "客户", time.Minute))
+	_, err = GetInto(mem, JSONCodec, "name", &count)
+	assert.NotNil(t, err)
+}
+
+func TestTypedRedis(t *testing.T) {
+	r := NewRedis(&RedisOpts{Host: "127.0.0.1:6379"})
+	conn := r.GetRedisPool().Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		t.Skipf("redis is not available: %v", err)
+	}
+
+	typed := NewTyped[typedValue](r, MsgpackCodec)
+	want := newTypedValue()
+	assert.Nil(t, typed.Set("typed_value", want, time.Minute))
+	got, ok, err := typed.Get("typed_value")
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, want.Count, got.Count)
+	assert.Nil(t, typed.Delete("typed_value"))
+}
@@ -0,0 +1,103 @@
+// Command yiqiying 亿企赢SDK辅助工具
+//
+//	yiqiying signature -appKey ak -timestamp 1650000000000 -version 1.0.0 -nonce n -param customerId=c1 -signature xxx
+//
+// appSecret可通过-appSecret或环境变量YIQIYING_APP_SECRET提供，输出中会打码
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yangzhenrui/finance/credential"
+)
+
+// appSecretEnv 未指定-appSecret时读取的环境变量，避免密钥出现在shell历史中
+const appSecretEnv = "YIQIYING_APP_SECRET"
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+// run 按子命令分发
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return usage(stderr)
+	}
+	switch args[0] {
+	case "signature":
+		return explainSignature(args[1:], stdout, stderr)
+	default:
+		return usage(stderr)
+	}
+}
+
+// usage 输出帮助
+func usage(w io.Writer) error {
+	fmt.Fprintln(w, "usage: yiqiying <command> [flags]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  signature  重新计算签名并与抓取到的签名比对")
+	return errors.New("unknown command")
+}
+
+// paramFlags 可重复的key=value参数
+type paramFlags map[string]string
+
+func (p paramFlags) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p paramFlags) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || kv[0] == "" {
+		return fmt.Errorf("invalid param %q, want key=value", value)
+	}
+	p[kv[0]] = kv[1]
+	return nil
+}
+
+// explainSignature signature子命令
+func explainSignature(args []string, stdout, stderr io.Writer) error {
+	var headers credential.CallbackHeaders
+	params := paramFlags{}
+	fs := flag.NewFlagSet("signature", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&headers.AppKey, "appKey", "", "请求头appKey")
+	appSecret := fs.String("appSecret", "", "appSecret，为空时读取环境变量"+appSecretEnv)
+	fs.StringVar(&headers.Timestamp, "timestamp", "", "请求头timestamp")
+	fs.StringVar(&headers.Version, "version", "1.0.0", "请求头version")
+	fs.StringVar(&headers.XReqNonce, "nonce", "", "请求头xReqNonce")
+	fs.StringVar(&headers.Signature, "signature", "", "抓取到的signature，为空时只输出计算过程")
+	fs.Var(params, "param", "参与签名的业务参数key=value，可重复")
+	algorithm := fs.String("algorithm", string(credential.HMACSHA256), "签名算法，HMAC-SHA256或HMAC-SM3")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *appSecret == "" {
+		*appSecret = os.Getenv(appSecretEnv)
+	}
+	if headers.AppKey == "" || *appSecret == "" || headers.Timestamp == "" || headers.XReqNonce == "" {
+		return errors.New("appKey, appSecret, timestamp and nonce are required")
+	}
+
+	explanation, err := credential.ExplainSignature(credential.Algorithm(*algorithm), *appSecret, headers, params)
+	if err != nil {
+		return err
+	}
+	if _, err := explanation.WriteTo(stdout); err != nil {
+		return err
+	}
+	if headers.Signature != "" && !explanation.Match() {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
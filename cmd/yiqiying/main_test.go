@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSignature(t *testing.T) {
+	args := []string{
+		"signature",
+		"-appKey", "appKey",
+		"-timestamp", "1650000000000",
+		"-nonce", "0123456789abcdef0123456789abcdef",
+		"-param", "customerId=c1",
+		"-param", "period=202205",
+		"-param", "taxCode=vat",
+	}
+	t.Setenv(appSecretEnv, "appSecret")
+
+	var stdout, stderr bytes.Buffer
+	err := run(append(args, "-signature", "lp2ZkUsII3pHbTyq+hJ0uYBqxOa6KO5+Hxao81A3afg="), &stdout, &stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, stdout.String(), "签名一致")
+	assert.NotContains(t, stdout.String(), "= appSecret\n")
+
+	stdout.Reset()
+	err = run(append(args, "-signature", "bad"), &stdout, &stderr)
+	assert.EqualError(t, err, "signature mismatch")
+	assert.Contains(t, stdout.String(), "签名不一致")
+
+	stdout.Reset()
+	err = run(append(args, "-algorithm", "HMAC-SM3", "-signature", "pApd+0k/ZSbYNuT9uWhcZzRF7jih2pB6noAWb84nldk="), &stdout, &stderr)
+	assert.Nil(t, err)
+	assert.Contains(t, stdout.String(), "HMAC-SM3")
+	assert.NotNil(t, run(append(args, "-algorithm", "HMAC-MD5"), &stdout, &stderr))
+
+	assert.NotNil(t, run([]string{"signature", "-param", "novalue"}, &stdout, &stderr))
+	assert.NotNil(t, run([]string{"signature"}, &stdout, &stderr))
+	assert.NotNil(t, run(nil, &stdout, &stderr))
+}
@@ -0,0 +1,55 @@
+package credential
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"net/url"
+
+	"github.com/tjfoc/gmsm/sm3"
+)
+
+// Algorithm 签名算法
+type Algorithm string
+
+const (
+	// HMACSHA256 网关默认的签名算法
+	HMACSHA256 Algorithm = "HMAC-SHA256"
+
+	// HMACSM3 国密签名算法，以SM3代替SHA256，其余步骤不变
+	HMACSM3 Algorithm = "HMAC-SM3"
+)
+
+// SignWithAlgorithm 以指定算法签名，algorithm为空时使用HMACSHA256
+func SignWithAlgorithm(algorithm Algorithm, appSecret string, mergeStr string) (string, error) {
+	switch algorithm {
+	case "", HMACSHA256:
+		return Sign(appSecret, mergeStr), nil
+	case HMACSM3:
+		return SignSM3(appSecret, mergeStr), nil
+	default:
+		return "", fmt.Errorf("credential: unsupported signature algorithm %q", algorithm)
+	}
+}
+
+// newHash 算法使用的哈希函数，algorithm为空时使用HMACSHA256
+func (algorithm Algorithm) newHash() (func() hash.Hash, error) {
+	switch algorithm {
+	case "", HMACSHA256:
+		return sha256.New, nil
+	case HMACSM3:
+		return sm3.New, nil
+	default:
+		return nil, fmt.Errorf("credential: unsupported signature algorithm %q", algorithm)
+	}
+}
+
+// SignSM3 对拼接后的字符串url编码，再以appSecret做HMAC-SM3并base64编码
+func SignSM3(appSecret string, mergeStr string) string {
+	encodedStr := url.QueryEscape(mergeStr)
+	hash := hmac.New(sm3.New, []byte(appSecret))
+	hash.Write([]byte(encodedStr))
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
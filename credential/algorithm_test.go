@@ -0,0 +1,39 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sm3GoldenVectors HMAC-SM3签名向量，结果已用openssl dgst -sm3 -hmac交叉验证
+var sm3GoldenVectors = map[string]string{
+	"tax":        "pApd+0k/ZSbYNuT9uWhcZzRF7jih2pB6noAWb84nldk=",
+	"url escape": "aGu41TjbWHEzFgb5XImQDLmTSbJeto01vYst9SjHo5M=",
+}
+
+func TestSignSM3(t *testing.T) {
+	for _, v := range signatureGoldenVectors {
+		want, ok := sm3GoldenVectors[v.name]
+		if !ok {
+			continue
+		}
+		assert.Equal(t, want, SignSM3(v.appSecret, v.mergeStr), v.name)
+
+		signature, err := NewSignatureWithAlgorithm(HMACSM3, v.appKey, v.appSecret, 1650000000000, "1.0.0", v.xReqNonce, v.params).GetSignature()
+		assert.Nil(t, err)
+		assert.Equal(t, want, signature, v.name)
+	}
+}
+
+func TestSignWithAlgorithm(t *testing.T) {
+	v := signatureGoldenVectors[0]
+	for _, algorithm := range []Algorithm{"", HMACSHA256} {
+		signature, err := SignWithAlgorithm(algorithm, v.appSecret, v.mergeStr)
+		assert.Nil(t, err)
+		assert.Equal(t, v.signature, signature)
+	}
+
+	_, err := NewSignatureWithAlgorithm("MD5", v.appKey, v.appSecret, 1650000000000, "1.0.0", v.xReqNonce, v.params).GetSignature()
+	assert.NotNil(t, err)
+}
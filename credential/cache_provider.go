@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yangzhenrui/finance/cache"
+)
+
+// CacheProvider 从cache读取租户凭证，未命中时从next获取并写入cache
+// 适合将凭证集中存放在redis中供多个实例共享
+type CacheProvider struct {
+	cache          cache.Cache
+	next           Provider
+	timeout        time.Duration
+	cacheKeyPrefix string
+}
+
+// NewCacheProvider 实例化，next可以为nil，此时仅从cache读取
+func NewCacheProvider(cache cache.Cache, next Provider, timeout time.Duration) *CacheProvider {
+	return &CacheProvider{
+		cache:          cache,
+		next:           next,
+		timeout:        timeout,
+		cacheKeyPrefix: CacheKeyYiQiYingPrefix + "credential_",
+	}
+}
+
+// CacheKey 租户凭证在cache中的key
+func (p *CacheProvider) CacheKey(tenantID string) string {
+	return p.cacheKeyPrefix + tenantID
+}
+
+// GetCredential 获取租户凭证
+func (p *CacheProvider) GetCredential(ctx context.Context, tenantID string) (Credential, error) {
+	if cred, ok := toCredential(p.cache.Get(p.CacheKey(tenantID))); ok {
+		return cred, nil
+	}
+	if p.next == nil {
+		return Credential{}, fmt.Errorf("%w: tenant %s", ErrCredentialNotFound, tenantID)
+	}
+
+	cred, err := p.next.GetCredential(ctx, tenantID)
+	if err != nil {
+		return Credential{}, err
+	}
+	// 缓存写入失败只影响下次是否命中，凭证已取到，不应让本次请求失败
+	_ = p.cache.Set(p.CacheKey(tenantID), cred, p.timeout)
+	return cred, nil
+}
+
+// toCredential redis、memcache会将结构体还原为map，需要兼容处理
+func toCredential(val interface{}) (Credential, bool) {
+	switch v := val.(type) {
+	case Credential:
+		return v, true
+	case *Credential:
+		if v == nil {
+			return Credential{}, false
+		}
+		return *v, true
+	case map[string]interface{}:
+		appKey, _ := v["appKey"].(string)
+		appSecret, _ := v["appSecret"].(string)
+		pendingSecret, _ := v["pendingSecret"].(string)
+		return Credential{AppKey: appKey, AppSecret: appSecret, PendingSecret: pendingSecret}, appKey != "" && appSecret != ""
+	default:
+		return Credential{}, false
+	}
+}
@@ -0,0 +1,167 @@
+package credential
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SignatureExplanation 一次签名的计算过程，用于排查网关拒绝签名的原因
+// 其中的appSecret均已打码，可以直接输出到日志或终端
+type SignatureExplanation struct {
+	Algorithm     Algorithm         // 签名算法
+	Params        map[string]string // 参与签名的参数
+	Keys          []string          // 按签名规则排序后的参数名
+	MergeString   string            // 按参数名升序拼接的参数值
+	EscapedString string            // url编码后的待签名字符串
+	Signature     string            // 计算得到的签名
+	Expected      string            // 待比对的签名，为空时不比对
+	Hints         []string          // 不一致时，与Expected相符的常见错误算法
+}
+
+// Match 计算得到的签名与Expected是否一致
+func (e *SignatureExplanation) Match() bool {
+	return e.Expected != "" && e.Expected == e.Signature
+}
+
+// ExplainSignature 按网关规则以algorithm重新计算签名，并与headers.Signature比对，algorithm为空时使用HMACSHA256
+// params为除appKey、appSecret、timestamp、version、xReqNonce外参与签名的参数
+func ExplainSignature(algorithm Algorithm, appSecret string, headers CallbackHeaders, params map[string]string) (*SignatureExplanation, error) {
+	newHash, err := algorithm.newHash()
+	if err != nil {
+		return nil, err
+	}
+	if algorithm == "" {
+		algorithm = HMACSHA256
+	}
+
+	signParams := make(map[string]string, len(params)+5)
+	for k, v := range params {
+		signParams[k] = v
+	}
+	signParams["appKey"] = headers.AppKey
+	signParams["appSecret"] = appSecret
+	signParams["timestamp"] = headers.Timestamp
+	signParams["version"] = headers.Version
+	signParams["xReqNonce"] = headers.XReqNonce
+
+	keys := make([]string, 0, len(signParams))
+	for k := range signParams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	mergeStr := MergeString(signParams)
+	escapedStr := url.QueryEscape(mergeStr)
+	signature, err := SignWithAlgorithm(algorithm, appSecret, mergeStr)
+	if err != nil {
+		return nil, err
+	}
+	e := &SignatureExplanation{
+		Algorithm:     algorithm,
+		Params:        signParams,
+		Keys:          keys,
+		MergeString:   maskedMergeString(keys, signParams, nil),
+		EscapedString: maskedMergeString(keys, signParams, url.QueryEscape),
+		Signature:     signature,
+		Expected:      headers.Signature,
+	}
+	e.Params["appSecret"] = MaskSecret(appSecret)
+	if e.Expected != "" && !e.Match() {
+		e.Hints = signatureHints(newHash, appSecret, mergeStr, escapedStr, e.Expected)
+	}
+	return e, nil
+}
+
+// signatureHints 尝试常见的错误算法，找出对方可能的签名方式
+func signatureHints(newHash func() hash.Hash, appSecret, mergeStr, escapedStr, expected string) []string {
+	mac := func(s string) []byte {
+		hash := hmac.New(newHash, []byte(appSecret))
+		hash.Write([]byte(s))
+		return hash.Sum(nil)
+	}
+	variants := []struct {
+		hint      string
+		signature string
+	}{
+		{"签名前未做url编码", base64.StdEncoding.EncodeToString(mac(mergeStr))},
+		{"使用了url.PathEscape编码", base64.StdEncoding.EncodeToString(mac(url.PathEscape(mergeStr)))},
+		{"使用了URL安全的base64编码", base64.URLEncoding.EncodeToString(mac(escapedStr))},
+		{"使用了十六进制编码而非base64", hex.EncodeToString(mac(escapedStr))},
+	}
+
+	var hints []string
+	for _, v := range variants {
+		if strings.EqualFold(v.signature, expected) {
+			hints = append(hints, v.hint)
+		}
+	}
+	return hints
+}
+
+// MaskSecret 打码密钥，仅保留首尾各两位
+func MaskSecret(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 4 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
+
+// maskedMergeString 按keys顺序拼接参数值，只将appSecret所在的一段打码，escape不为nil时对其余各段编码
+// url编码按字节进行，逐段编码后拼接与整体编码的结果一致
+func maskedMergeString(keys []string, params map[string]string, escape func(string) string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		v := params[k]
+		switch {
+		case k == "appSecret":
+			v = MaskSecret(v)
+		case escape != nil:
+			v = escape(v)
+		}
+		b.WriteString(v)
+	}
+	return b.String()
+}
+
+// WriteTo 输出可读的计算过程
+func (e *SignatureExplanation) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "签名算法:     %s\n", e.Algorithm)
+	b.WriteString("参与签名的参数(按参数名升序):\n")
+	for _, k := range e.Keys {
+		fmt.Fprintf(&b, "  %s = %s\n", k, e.Params[k])
+	}
+	fmt.Fprintf(&b, "拼接字符串:   %s\n", e.MergeString)
+	fmt.Fprintf(&b, "url编码后:    %s\n", e.EscapedString)
+	fmt.Fprintf(&b, "计算的签名:   %s\n", e.Signature)
+	if e.Expected != "" {
+		fmt.Fprintf(&b, "提供的签名:   %s\n", e.Expected)
+		if e.Match() {
+			b.WriteString("结果: 签名一致\n")
+		} else {
+			fmt.Fprintf(&b, "结果: 签名不一致，自第%d个字符起不同\n", firstDiff(e.Signature, e.Expected)+1)
+			for _, hint := range e.Hints {
+				fmt.Fprintf(&b, "提示: 提供的签名与%s的结果一致\n", hint)
+			}
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// firstDiff 第一个不同字符的下标
+func firstDiff(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,108 @@
+package credential
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainSignature(t *testing.T) {
+	for _, v := range signatureGoldenVectors {
+		headers := CallbackHeaders{
+			AppKey:    v.appKey,
+			Timestamp: "1650000000000",
+			Version:   "1.0.0",
+			XReqNonce: v.xReqNonce,
+			Signature: v.signature,
+		}
+		e := mustExplain(t, HMACSHA256, v.appSecret, headers, v.params)
+		assert.True(t, e.Match(), v.name)
+		assert.Equal(t, v.signature, e.Signature, v.name)
+		assert.Equal(t, MaskSecret(v.appSecret), e.Params["appSecret"], v.name)
+		assert.NotContains(t, e.MergeString, v.appSecret, v.name)
+		assert.Empty(t, e.Hints, v.name)
+	}
+}
+
+func TestExplainSignatureMismatch(t *testing.T) {
+	v := signatureGoldenVectors[0]
+	headers := CallbackHeaders{
+		AppKey:    v.appKey,
+		Timestamp: "1650000000000",
+		Version:   "1.0.0",
+		XReqNonce: v.xReqNonce,
+	}
+	e := mustExplain(t, HMACSHA256, v.appSecret, headers, v.params)
+	assert.False(t, e.Match())
+	assert.Equal(t, "appKeyap*****etc1202205vat16500000000001.0.00123456789abcdef0123456789abcdef", e.MergeString)
+
+	// 对方使用了十六进制编码
+	hash := hmac.New(sha256.New, []byte(v.appSecret))
+	hash.Write([]byte(v.mergeStr))
+	headers.Signature = hex.EncodeToString(hash.Sum(nil))
+	e = mustExplain(t, HMACSHA256, v.appSecret, headers, v.params)
+	assert.False(t, e.Match())
+	assert.Equal(t, []string{"使用了十六进制编码而非base64"}, e.Hints)
+
+	var out bytes.Buffer
+	_, err := e.WriteTo(&out)
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "签名不一致，自第1个字符起不同")
+	assert.Contains(t, out.String(), "十六进制")
+	assert.NotContains(t, out.String(), "= appSecret\n")
+	assert.NotContains(t, out.String(), "appKeyappSecret")
+}
+
+func TestExplainSignatureEscapedSecret(t *testing.T) {
+	v := signatureGoldenVectors[3]
+	e := mustExplain(t, HMACSHA256, v.appSecret, CallbackHeaders{AppKey: v.appKey, Timestamp: "1650000000000", Version: "1.0.0", XReqNonce: v.xReqNonce}, v.params)
+	assert.NotContains(t, e.EscapedString, "s%2Fk%2B%3D")
+	assert.Contains(t, e.EscapedString, "s/*+=")
+}
+
+func TestExplainSignatureSecretInParams(t *testing.T) {
+	// 其他参数的值中出现与appSecret相同的文本时不应被打码
+	headers := CallbackHeaders{AppKey: "secret-key", Timestamp: "1650000000000", Version: "1.0.0", XReqNonce: "nonce"}
+	e := mustExplain(t, HMACSHA256, "secret", headers, map[string]string{"customerId": "c/secret"})
+	assert.Equal(t, "secret-keyse**etc/secret16500000000001.0.0nonce", e.MergeString)
+	assert.Equal(t, "secret-keyse**etc%2Fsecret16500000000001.0.0nonce", e.EscapedString)
+}
+
+func TestExplainSignatureSM3(t *testing.T) {
+	v := signatureGoldenVectors[0]
+	headers := CallbackHeaders{
+		AppKey:    v.appKey,
+		Timestamp: "1650000000000",
+		Version:   "1.0.0",
+		XReqNonce: v.xReqNonce,
+		Signature: sm3GoldenVectors[v.name],
+	}
+	e := mustExplain(t, HMACSM3, v.appSecret, headers, v.params)
+	assert.True(t, e.Match())
+	assert.Equal(t, HMACSM3, e.Algorithm)
+
+	// 对方使用了HMAC-SM3而本地按HMAC-SHA256比对
+	e = mustExplain(t, "", v.appSecret, headers, v.params)
+	assert.Equal(t, HMACSHA256, e.Algorithm)
+	assert.False(t, e.Match())
+
+	_, err := ExplainSignature("HMAC-MD5", v.appSecret, headers, v.params)
+	assert.NotNil(t, err)
+}
+
+func mustExplain(t *testing.T, algorithm Algorithm, appSecret string, headers CallbackHeaders, params map[string]string) *SignatureExplanation {
+	e, err := ExplainSignature(algorithm, appSecret, headers, params)
+	assert.Nil(t, err)
+	return e
+}
+
+func TestMaskSecret(t *testing.T) {
+	assert.Equal(t, "", MaskSecret(""))
+	assert.Equal(t, "****", MaskSecret("abcd"))
+	assert.Equal(t, "ab*ef", MaskSecret("abcef"))
+	assert.Equal(t, "密钥**23", MaskSecret("密钥ab23"))
+}
@@ -4,10 +4,12 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
-	"fmt"
-	"github.com/yangzhenrui/finance/cache"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+
+	"github.com/yangzhenrui/finance/cache"
 )
 
 const (
@@ -17,95 +19,81 @@ const (
 
 // Signature 默认signature 获取
 type Signature struct {
-	accountPeriod  *string
-	appKey         string
-	appSecret      string
-	customerId     *string
-	period         *string
-	reclassifyFlag *string
-	taxCode        *string
-	timestamp      int64
-	version        string
-	xReqNonce      string
-	cacheKeyPrefix string
-	cache          cache.Cache
+	algorithm Algorithm
+	appSecret string
+	params    map[string]string
+}
+
+// NewSignature 对任意业务参数签名，params为除appKey、appSecret、timestamp、version、xReqNonce外参与签名的参数
+func NewSignature(appKey string, appSecret string, timestamp int64, version string, xReqNonce string, params map[string]string) SignatureHandle {
+	return NewSignatureWithAlgorithm(HMACSHA256, appKey, appSecret, timestamp, version, xReqNonce, params)
+}
+
+// NewSignatureWithAlgorithm 同NewSignature，使用指定的签名算法，algorithm为空时使用HMACSHA256
+func NewSignatureWithAlgorithm(algorithm Algorithm, appKey string, appSecret string, timestamp int64, version string, xReqNonce string, params map[string]string) SignatureHandle {
+	signParams := make(map[string]string, len(params)+5)
+	for k, v := range params {
+		signParams[k] = v
+	}
+	signParams["appKey"] = appKey
+	signParams["appSecret"] = appSecret
+	signParams["timestamp"] = strconv.FormatInt(timestamp, 10)
+	signParams["version"] = version
+	signParams["xReqNonce"] = xReqNonce
+
+	return &Signature{
+		algorithm: algorithm,
+		appSecret: appSecret,
+		params:    signParams,
+	}
 }
 
 // NewDefaultSignature new NewDefaultSignature
+// Deprecated: 仅支持固定的签名参数，新接口请使用NewSignature
 func NewDefaultSignature(accountPeriod *string, appKey string, appSecret string, customerId *string, period *string, reclassifyFlag *string, taxCode *string, timestamp int64, version string, xReqNonce string, cacheKeyPrefix string, cache cache.Cache) SignatureHandle {
 	if cache == nil {
 		panic("cache is ineed")
 	}
 
-	var s Signature
-	if accountPeriod != nil {
-		s.accountPeriod = accountPeriod
-	}
-	s.appKey = appKey
-	s.appSecret = appSecret
-	if customerId != nil {
-		s.customerId = customerId
-	}
-	if reclassifyFlag != nil {
-		s.reclassifyFlag = reclassifyFlag
+	params := map[string]string{}
+	for k, v := range map[string]*string{
+		"accountPeriod":  accountPeriod,
+		"customerId":     customerId,
+		"period":         period,
+		"reclassifyFlag": reclassifyFlag,
+		"taxCode":        taxCode,
+	} {
+		if v != nil {
+			params[k] = *v
+		}
 	}
-	if period != nil {
-		s.period = period
-	}
-	if taxCode != nil {
-		s.taxCode = taxCode
-	}
-
-	s.timestamp = timestamp
-	s.version = version
-	s.xReqNonce = xReqNonce
-	s.cache = cache
-	s.cacheKeyPrefix = cacheKeyPrefix
-
-	return &s
-	//return &Signature{
-	//	appKey:         appKey,
-	//	appSecret:      appSecret,
-	//	timestamp:      timestamp,
-	//	version:        version,
-	//	xReqNonce:      xReqNonce,
-	//	cache:          cache,
-	//	cacheKeyPrefix: cacheKeyPrefix,
-	//}
+	return NewSignature(appKey, appSecret, timestamp, version, xReqNonce, params)
 }
 
-// GetSignature 获取signature,先从cache中获取，没有则重新生成
+// GetSignature 获取signature
 func (s *Signature) GetSignature() (signature string, err error) {
-	// 先从cache中取
-	//signatureCacheKey := fmt.Sprintf("%s_signature_%s", s.cacheKeyPrefix, s.appKey)
-	//if val := s.cache.Get(signatureCacheKey); val != nil {
-	//	return val.(string), nil
-	//}
+	return SignWithAlgorithm(s.algorithm, s.appSecret, MergeString(s.params))
+}
 
-	// 生成signature
-	var accountPeriod, customerId, reclassifyFlag, period, taxCode string = "", "", "", "", ""
-	if s.accountPeriod != nil {
-		accountPeriod = *s.accountPeriod
-	}
-	if s.customerId != nil {
-		customerId = *s.customerId
+// MergeString 按参数名升序拼接参数值，得到待签名的字符串
+func MergeString(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
 	}
-	if s.reclassifyFlag != nil {
-		reclassifyFlag = *s.reclassifyFlag
-	}
-	if s.taxCode != nil {
-		taxCode = *s.taxCode
-	}
-	if s.period != nil {
-		period = *s.period
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, k := range keys {
+		builder.WriteString(params[k])
 	}
-	mergeStr := fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s", accountPeriod, s.appKey, s.appSecret, customerId, period, reclassifyFlag, taxCode, strconv.FormatInt(s.timestamp, 10), s.version, s.xReqNonce)
+	return builder.String()
+}
+
+// Sign 对拼接后的字符串url编码，再以appSecret做HMAC-SHA256并base64编码
+func Sign(appSecret string, mergeStr string) string {
 	encodedStr := url.QueryEscape(mergeStr)
-	hash := hmac.New(sha256.New, []byte(s.appSecret))
+	hash := hmac.New(sha256.New, []byte(appSecret))
 	hash.Write([]byte(encodedStr))
-	signData := hash.Sum(nil)
-	sign := base64.StdEncoding.EncodeToString(signData)
-	signature = sign
-
-	return
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
 }
@@ -0,0 +1,118 @@
+package credential
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+)
+
+// signatureGoldenVectors 固定的签名向量，修改签名算法时不应改变这些结果
+// 结果已用openssl dgst -sha256 -hmac对url编码后的mergeStr交叉验证，与重构前NewDefaultSignature的输出一致
+// 这些向量只能证明实现前后一致，与网关的一致性由documentedSignatureVectors保证
+var signatureGoldenVectors = []struct {
+	name      string
+	appKey    string
+	appSecret string
+	xReqNonce string
+	params    map[string]string
+	mergeStr  string
+	signature string
+}{
+	{
+		name:      "tax",
+		appKey:    "appKey",
+		appSecret: "appSecret",
+		xReqNonce: "0123456789abcdef0123456789abcdef",
+		params:    map[string]string{"customerId": "c1", "period": "202205", "taxCode": "vat"},
+		mergeStr:  "appKeyappSecretc1202205vat16500000000001.0.00123456789abcdef0123456789abcdef",
+		signature: "lp2ZkUsII3pHbTyq+hJ0uYBqxOa6KO5+Hxao81A3afg=",
+	},
+	{
+		name:      "finance",
+		appKey:    "appKey",
+		appSecret: "appSecret",
+		xReqNonce: "nonce",
+		params:    map[string]string{"accountPeriod": "202205", "customerId": "c1", "reclassifyFlag": "1"},
+		mergeStr:  "202205appKeyappSecretc1116500000000001.0.0nonce",
+		signature: "1o+onCqyLz8ek4yeMk7ZJGx/gi0h9ZNXbxGXgH1HEbw=",
+	},
+	{
+		name:      "no params",
+		appKey:    "appKey",
+		appSecret: "appSecret",
+		xReqNonce: "nonce",
+		mergeStr:  "appKeyappSecret16500000000001.0.0nonce",
+		signature: "KCSbyTVZ2Q/ymnerqWtqjanLRVaorPfSZ0dLgIn+mLo=",
+	},
+	{
+		name:      "url escape",
+		appKey:    "ak",
+		appSecret: "s/k+=",
+		xReqNonce: "nonce",
+		params:    map[string]string{"customerId": "客户 1"},
+		mergeStr:  "aks/k+=客户 116500000000001.0.0nonce",
+		signature: "eNpCAm7MeudNg0Eh1wXycSzp3W2VCjL7/MXkt5tCHDg=",
+	},
+}
+
+// documentedSignatureVectors 17win网关文档中的签名示例，source注明文档章节
+// 尚未收录：文档站点无法访问，原样摘录后补充，不得使用本实现计算的结果
+var documentedSignatureVectors = []struct {
+	source    string
+	appKey    string
+	appSecret string
+	timestamp int64
+	version   string
+	xReqNonce string
+	params    map[string]string
+	signature string
+}{}
+
+func TestDocumentedSignatureVectors(t *testing.T) {
+	if len(documentedSignatureVectors) == 0 {
+		t.Skip("17win网关文档中的签名示例尚未收录")
+	}
+	for _, v := range documentedSignatureVectors {
+		signature, err := NewSignature(v.appKey, v.appSecret, v.timestamp, v.version, v.xReqNonce, v.params).GetSignature()
+		assert.Nil(t, err)
+		assert.Equal(t, v.signature, signature, v.source)
+	}
+}
+
+func TestNewSignature(t *testing.T) {
+	for _, v := range signatureGoldenVectors {
+		s := NewSignature(v.appKey, v.appSecret, 1650000000000, "1.0.0", v.xReqNonce, v.params).(*Signature)
+		assert.Equal(t, v.mergeStr, MergeString(s.params), v.name)
+		signature, err := s.GetSignature()
+		assert.Nil(t, err)
+		assert.Equal(t, v.signature, signature, v.name)
+	}
+}
+
+func TestNewDefaultSignature(t *testing.T) {
+	ptr := func(v string) *string {
+		return &v
+	}
+	for _, v := range signatureGoldenVectors {
+		var accountPeriod, customerId, period, reclassifyFlag, taxCode *string
+		if value, ok := v.params["accountPeriod"]; ok {
+			accountPeriod = ptr(value)
+		}
+		if value, ok := v.params["customerId"]; ok {
+			customerId = ptr(value)
+		}
+		if value, ok := v.params["period"]; ok {
+			period = ptr(value)
+		}
+		if value, ok := v.params["reclassifyFlag"]; ok {
+			reclassifyFlag = ptr(value)
+		}
+		if value, ok := v.params["taxCode"]; ok {
+			taxCode = ptr(value)
+		}
+		signature, err := NewDefaultSignature(accountPeriod, v.appKey, v.appSecret, customerId, period, reclassifyFlag, taxCode, 1650000000000, "1.0.0", v.xReqNonce, CacheKeyYiQiYingPrefix, cache.NewMemory()).GetSignature()
+		assert.Nil(t, err)
+		assert.Equal(t, v.signature, signature, v.name)
+	}
+}
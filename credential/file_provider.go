@@ -0,0 +1,64 @@
+package credential
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider 从json或yaml文件读取租户凭证，文件内容为租户ID->凭证的映射
+type FileProvider struct {
+	path        string
+	mu          sync.RWMutex
+	credentials map[string]Credential
+}
+
+// NewFileProvider 加载凭证文件，.yaml、.yml按yaml解析，其余按json解析
+func NewFileProvider(path string) (*FileProvider, error) {
+	p := &FileProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload 重新加载凭证文件
+func (p *FileProvider) Reload() error {
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	credentials := map[string]Credential{}
+	switch strings.ToLower(filepath.Ext(p.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &credentials)
+	default:
+		err = json.Unmarshal(data, &credentials)
+	}
+	if err != nil {
+		return fmt.Errorf("credential: parse %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.credentials = credentials
+	return nil
+}
+
+// GetCredential 获取租户凭证
+func (p *FileProvider) GetCredential(ctx context.Context, tenantID string) (Credential, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if cred, ok := p.credentials[tenantID]; ok {
+		return cred, nil
+	}
+	return Credential{}, fmt.Errorf("%w: tenant %s", ErrCredentialNotFound, tenantID)
+}
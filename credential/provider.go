@@ -0,0 +1,92 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrCredentialNotFound 找不到租户对应的凭证
+var ErrCredentialNotFound = errors.New("credential: credential not found")
+
+// Credential 亿企赢应用凭证
+type Credential struct {
+	AppKey    string `json:"appKey" yaml:"appKey"`
+	AppSecret string `json:"appSecret" yaml:"appSecret"`
+
+	// PendingSecret 待生效密钥，AppSecret被网关拒绝时改用其重试一次，成功后提升为当前密钥
+	PendingSecret string `json:"pendingSecret,omitempty" yaml:"pendingSecret,omitempty"`
+}
+
+// Provider 按租户提供凭证，每次请求都会调用
+type Provider interface {
+	GetCredential(ctx context.Context, tenantID string) (Credential, error)
+}
+
+type tenantKey struct{}
+
+// WithTenant 在ctx中设置租户ID，请求时以此从Provider获取凭证
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext 获取ctx中的租户ID
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// StaticProvider 以固定的租户ID->凭证映射提供凭证
+type StaticProvider map[string]Credential
+
+// GetCredential 获取租户凭证
+func (p StaticProvider) GetCredential(ctx context.Context, tenantID string) (Credential, error) {
+	if cred, ok := p[tenantID]; ok {
+		return cred, nil
+	}
+	return Credential{}, fmt.Errorf("%w: tenant %s", ErrCredentialNotFound, tenantID)
+}
+
+// EnvProvider 从环境变量读取凭证
+// 变量名为<Prefix>_<TENANT>_APP_KEY及<Prefix>_<TENANT>_APP_SECRET，TENANT为大写的租户ID，非字母数字替换为下划线
+// 轮换密钥期间可设置<Prefix>_<TENANT>_PENDING_SECRET
+type EnvProvider struct {
+	Prefix string
+}
+
+// DefaultEnvPrefix EnvProvider默认的环境变量前缀
+const DefaultEnvPrefix = "YIQIYING"
+
+// GetCredential 获取租户凭证
+func (p EnvProvider) GetCredential(ctx context.Context, tenantID string) (Credential, error) {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	name := prefix + "_" + envName(tenantID)
+	cred := Credential{
+		AppKey:        os.Getenv(name + "_APP_KEY"),
+		AppSecret:     os.Getenv(name + "_APP_SECRET"),
+		PendingSecret: os.Getenv(name + "_PENDING_SECRET"),
+	}
+	if cred.AppKey == "" || cred.AppSecret == "" {
+		return Credential{}, fmt.Errorf("%w: tenant %s", ErrCredentialNotFound, tenantID)
+	}
+	return cred, nil
+}
+
+// envName 转换为环境变量名
+func envName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
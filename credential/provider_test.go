@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+)
+
+func TestTenantFromContext(t *testing.T) {
+	_, ok := TenantFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = TenantFromContext(WithTenant(context.Background(), ""))
+	assert.False(t, ok)
+
+	tenantID, ok := TenantFromContext(WithTenant(context.Background(), "t1"))
+	assert.True(t, ok)
+	assert.Equal(t, "t1", tenantID)
+}
+
+func TestStaticProvider(t *testing.T) {
+	p := StaticProvider{"t1": {AppKey: "k1", AppSecret: "s1"}}
+	cred, err := p.GetCredential(context.Background(), "t1")
+	assert.Nil(t, err)
+	assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1"}, cred)
+
+	_, err = p.GetCredential(context.Background(), "t2")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("YIQIYING_ORG_1_APP_KEY", "k1")
+	t.Setenv("YIQIYING_ORG_1_APP_SECRET", "s1")
+	t.Setenv("CUSTOM_ORG2_APP_KEY", "k2")
+
+	cred, err := EnvProvider{}.GetCredential(context.Background(), "org-1")
+	assert.Nil(t, err)
+	assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1"}, cred)
+
+	t.Setenv("YIQIYING_ORG_1_PENDING_SECRET", "s2")
+	cred, err = EnvProvider{}.GetCredential(context.Background(), "org-1")
+	assert.Nil(t, err)
+	assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1", PendingSecret: "s2"}, cred)
+
+	// 缺少appSecret视为未找到
+	_, err = EnvProvider{Prefix: "CUSTOM"}.GetCredential(context.Background(), "org2")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"credentials.json": `{"t1":{"appKey":"k1","appSecret":"s1"}}`,
+		"credentials.yaml": "t1:\n  appKey: k1\n  appSecret: s1\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+		p, err := NewFileProvider(path)
+		assert.Nil(t, err)
+		cred, err := p.GetCredential(context.Background(), "t1")
+		assert.Nil(t, err)
+		assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1"}, cred, name)
+		_, err = p.GetCredential(context.Background(), "t2")
+		assert.ErrorIs(t, err, ErrCredentialNotFound)
+	}
+
+	path := filepath.Join(dir, "credentials.json")
+	p, err := NewFileProvider(path)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`{"t2":{"appKey":"k2","appSecret":"s2"}}`), 0600))
+	assert.Nil(t, p.Reload())
+	cred, err := p.GetCredential(context.Background(), "t2")
+	assert.Nil(t, err)
+	assert.Equal(t, "k2", cred.AppKey)
+
+	_, err = NewFileProvider(filepath.Join(dir, "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, ioutil.WriteFile(path, []byte(`not json`), 0600))
+	assert.NotNil(t, p.Reload())
+}
+
+type countingProvider struct {
+	calls int
+}
+
+func (p *countingProvider) GetCredential(ctx context.Context, tenantID string) (Credential, error) {
+	p.calls++
+	return StaticProvider{"t1": {AppKey: "k1", AppSecret: "s1"}}.GetCredential(ctx, tenantID)
+}
+
+func TestCacheProvider(t *testing.T) {
+	next := &countingProvider{}
+	p := NewCacheProvider(cache.NewMemory(), next, time.Minute)
+	for i := 0; i < 2; i++ {
+		cred, err := p.GetCredential(context.Background(), "t1")
+		assert.Nil(t, err)
+		assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1"}, cred)
+	}
+	assert.Equal(t, 1, next.calls)
+
+	_, err := p.GetCredential(context.Background(), "t2")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	// redis、memcache取回的值为map
+	c := cache.NewMemory()
+	p = NewCacheProvider(c, nil, time.Minute)
+	assert.Nil(t, c.Set(p.CacheKey("t3"), map[string]interface{}{"appKey": "k3", "appSecret": "s3"}, time.Minute))
+	cred, err := p.GetCredential(context.Background(), "t3")
+	assert.Nil(t, err)
+	assert.Equal(t, Credential{AppKey: "k3", AppSecret: "s3"}, cred)
+	_, err = p.GetCredential(context.Background(), "t1")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	// 缓存中的nil指针视为未命中
+	var nilCred *Credential
+	assert.Nil(t, c.Set(p.CacheKey("t4"), nilCred, time.Minute))
+	_, err = p.GetCredential(context.Background(), "t4")
+	assert.ErrorIs(t, err, ErrCredentialNotFound)
+
+	// 缓存写入失败时仍返回凭证
+	next = &countingProvider{}
+	p = NewCacheProvider(failingCache{cache.NewMemory()}, next, time.Minute)
+	cred, err = p.GetCredential(context.Background(), "t1")
+	assert.Nil(t, err)
+	assert.Equal(t, Credential{AppKey: "k1", AppSecret: "s1"}, cred)
+}
+
+// failingCache 写入总是失败的缓存
+type failingCache struct {
+	cache.Cache
+}
+
+func (failingCache) Set(key string, val interface{}, timeout time.Duration) error {
+	return errors.New("cache unavailable")
+}
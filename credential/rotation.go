@@ -0,0 +1,92 @@
+package credential
+
+import "sync"
+
+// RotationEventType 密钥轮换事件类型
+type RotationEventType string
+
+const (
+	// RotationPendingTried 当前密钥被网关拒绝，改用待生效密钥重试
+	RotationPendingTried RotationEventType = "pending_tried"
+
+	// RotationPromoted 待生效密钥验证通过，已提升为当前密钥
+	RotationPromoted RotationEventType = "promoted"
+
+	// RotationPendingRejected 待生效密钥同样被网关拒绝
+	RotationPendingRejected RotationEventType = "pending_rejected"
+)
+
+// RotationEvent 密钥轮换事件，可用于告警
+type RotationEvent struct {
+	Type   RotationEventType
+	AppKey string
+	Err    error // 触发本次事件的网关错误，Promoted时为nil
+}
+
+// RotationListener 接收密钥轮换事件，需自行保证并发安全
+type RotationListener func(event RotationEvent)
+
+// Rotator 记录各appKey已提升的待生效密钥，并发安全
+// 网关更换appSecret后，只需在配置中加入PendingSecret，无需重新部署
+type Rotator struct {
+	mu        sync.RWMutex
+	promoted  map[string]string
+	listeners []RotationListener
+}
+
+// NewRotator 实例化
+func NewRotator(listeners ...RotationListener) *Rotator {
+	return &Rotator{
+		promoted:  map[string]string{},
+		listeners: listeners,
+	}
+}
+
+// Subscribe 添加事件监听
+func (r *Rotator) Subscribe(listener RotationListener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// Active 返回当前应使用的凭证
+// PendingSecret已被提升时以其作为AppSecret；配置已更新为新密钥时直接使用配置
+func (r *Rotator) Active(cred Credential) Credential {
+	r.mu.RLock()
+	secret, ok := r.promoted[cred.AppKey]
+	r.mu.RUnlock()
+
+	if ok && secret != "" && secret == cred.PendingSecret {
+		return Credential{AppKey: cred.AppKey, AppSecret: secret}
+	}
+	return cred
+}
+
+// Promote 将cred.PendingSecret提升为当前密钥，返回是否由本次调用完成提升
+// 并发请求同时以待生效密钥成功时只提升一次，RotationPromoted也只通知一次
+func (r *Rotator) Promote(cred Credential) bool {
+	if cred.PendingSecret == "" {
+		return false
+	}
+	r.mu.Lock()
+	if r.promoted[cred.AppKey] == cred.PendingSecret {
+		r.mu.Unlock()
+		return false
+	}
+	r.promoted[cred.AppKey] = cred.PendingSecret
+	r.mu.Unlock()
+
+	r.Emit(RotationEvent{Type: RotationPromoted, AppKey: cred.AppKey})
+	return true
+}
+
+// Emit 通知所有监听者
+func (r *Rotator) Emit(event RotationEvent) {
+	r.mu.RLock()
+	listeners := r.listeners
+	r.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,59 @@
+package credential
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotator(t *testing.T) {
+	var events []RotationEvent
+	r := NewRotator()
+	r.Subscribe(func(event RotationEvent) {
+		events = append(events, event)
+	})
+
+	cred := Credential{AppKey: "k1", AppSecret: "old", PendingSecret: "new"}
+	assert.Equal(t, cred, r.Active(cred))
+
+	assert.True(t, r.Promote(cred))
+	assert.False(t, r.Promote(cred))
+	assert.Equal(t, []RotationEvent{{Type: RotationPromoted, AppKey: "k1"}}, events)
+	assert.Equal(t, Credential{AppKey: "k1", AppSecret: "new"}, r.Active(cred))
+
+	// 配置已更新为新密钥
+	updated := Credential{AppKey: "k1", AppSecret: "new"}
+	assert.Equal(t, updated, r.Active(updated))
+	// 又一轮轮换，已提升的旧记录不再生效
+	next := Credential{AppKey: "k1", AppSecret: "new", PendingSecret: "newer"}
+	assert.Equal(t, next, r.Active(next))
+	// 其他appKey不受影响
+	other := Credential{AppKey: "k2", AppSecret: "old", PendingSecret: "new"}
+	assert.Equal(t, other, r.Active(other))
+
+	assert.False(t, r.Promote(Credential{AppKey: "k2", AppSecret: "old"}))
+	assert.Len(t, events, 1)
+}
+
+func TestRotatorPromoteConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	var events int
+	r := NewRotator(func(event RotationEvent) {
+		mu.Lock()
+		events++
+		mu.Unlock()
+	})
+
+	cred := Credential{AppKey: "k1", AppSecret: "old", PendingSecret: "new"}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Promote(cred)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, events)
+}
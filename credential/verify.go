@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yangzhenrui/finance/cache"
+)
+
+const (
+	// DefaultTimestampWindow 回调timestamp与本地时间允许的最大偏差
+	DefaultTimestampWindow = 5 * time.Minute
+)
+
+var (
+	// ErrAppKeyMismatch 回调的appKey与本地配置不一致
+	ErrAppKeyMismatch = errors.New("credential: appKey mismatch")
+
+	// ErrTimestampExpired 回调的timestamp超出允许的时间窗口
+	ErrTimestampExpired = errors.New("credential: timestamp out of window")
+
+	// ErrNonceMissing 回调未携带xReqNonce
+	ErrNonceMissing = errors.New("credential: xReqNonce missing")
+
+	// ErrNonceReused 回调的xReqNonce已经使用过
+	ErrNonceReused = errors.New("credential: xReqNonce already used")
+
+	// ErrSignatureMismatch 回调的signature校验失败
+	ErrSignatureMismatch = errors.New("credential: signature mismatch")
+)
+
+// CallbackHeaders 亿企赢回调携带的签名相关请求头
+type CallbackHeaders struct {
+	AppKey    string
+	Timestamp string
+	Version   string
+	XReqNonce string
+	Signature string
+}
+
+// HeadersFromRequest 从回调请求中读取签名相关请求头
+func HeadersFromRequest(r *http.Request) CallbackHeaders {
+	return CallbackHeaders{
+		AppKey:    r.Header.Get("appKey"),
+		Timestamp: r.Header.Get("timestamp"),
+		Version:   r.Header.Get("version"),
+		XReqNonce: r.Header.Get("xReqNonce"),
+		Signature: r.Header.Get("signature"),
+	}
+}
+
+// Verifier 校验亿企赢回调的签名，并通过cache拒绝重放的xReqNonce
+// 多实例部署时cache应使用redis等共享存储，cache实现cache.Adder时nonce的判断与写入是原子的，
+// 否则只能在本实例内加锁，多实例同时收到重放请求时可能都校验通过
+type Verifier struct {
+	algorithm Algorithm
+	appKey    string
+	appSecret string
+	window    time.Duration
+	cache     cache.Cache
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewVerifier 实例化HMAC-SHA256回调签名校验，window小于等于0时使用DefaultTimestampWindow
+func NewVerifier(appKey, appSecret string, window time.Duration, cache cache.Cache) *Verifier {
+	return NewVerifierWithAlgorithm(HMACSHA256, appKey, appSecret, window, cache)
+}
+
+// NewVerifierWithAlgorithm 以指定签名算法实例化回调签名校验，algorithm为空时使用HMACSHA256
+func NewVerifierWithAlgorithm(algorithm Algorithm, appKey, appSecret string, window time.Duration, cache cache.Cache) *Verifier {
+	if cache == nil {
+		panic("cache is ineed")
+	}
+	if window <= 0 {
+		window = DefaultTimestampWindow
+	}
+	return &Verifier{
+		algorithm: algorithm,
+		appKey:    appKey,
+		appSecret: appSecret,
+		window:    window,
+		cache:     cache,
+		now:       time.Now,
+	}
+}
+
+// Verify 校验回调请求，params为参与签名的业务参数，如customerId、period
+func (v *Verifier) Verify(headers CallbackHeaders, params map[string]string) error {
+	if headers.AppKey != v.appKey {
+		return ErrAppKeyMismatch
+	}
+	if headers.XReqNonce == "" {
+		return ErrNonceMissing
+	}
+
+	timestamp, err := strconv.ParseInt(headers.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTimestampExpired, err)
+	}
+	skew := v.now().Sub(time.Unix(0, timestamp*int64(time.Millisecond)))
+	if skew > v.window || skew < -v.window {
+		return ErrTimestampExpired
+	}
+
+	expected, err := v.sign(timestamp, headers, params)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(headers.Signature)) != 1 {
+		return ErrSignatureMismatch
+	}
+
+	// 签名通过后再记录nonce，避免伪造的请求占用nonce
+	nonceKey := fmt.Sprintf("%snonce_%s_%s", CacheKeyYiQiYingPrefix, v.appKey, headers.XReqNonce)
+	if adder, ok := v.cache.(cache.Adder); ok {
+		added, err := adder.Add(nonceKey, timestamp, 2*v.window)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return ErrNonceReused
+		}
+		return nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.cache.IsExist(nonceKey) {
+		return ErrNonceReused
+	}
+	return v.cache.Set(nonceKey, timestamp, 2*v.window)
+}
+
+// sign 按出站请求相同的算法计算签名
+func (v *Verifier) sign(timestamp int64, headers CallbackHeaders, params map[string]string) (string, error) {
+	return NewSignatureWithAlgorithm(v.algorithm, v.appKey, v.appSecret, timestamp, headers.Version, headers.XReqNonce, params).GetSignature()
+}
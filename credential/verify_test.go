@@ -0,0 +1,129 @@
+package credential
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+)
+
+func signCallback(t *testing.T, timestamp int64, nonce string, customerID string) *http.Request {
+	signature, err := NewDefaultSignature(nil, "appKey", "appSecret", &customerID, nil, nil, nil, timestamp, "1.0.0", nonce, CacheKeyYiQiYingPrefix, cache.NewMemory()).GetSignature()
+	assert.Nil(t, err)
+
+	r, _ := http.NewRequest(http.MethodPost, "/callback", nil)
+	r.Header.Set("appKey", "appKey")
+	r.Header.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	r.Header.Set("version", "1.0.0")
+	r.Header.Set("xReqNonce", nonce)
+	r.Header.Set("signature", signature)
+	return r
+}
+
+func TestVerifier(t *testing.T) {
+	now := time.Unix(1650000000, 0)
+	v := NewVerifier("appKey", "appSecret", time.Minute, cache.NewMemory())
+	v.now = func() time.Time {
+		return now
+	}
+	timestamp := now.UnixNano() / 1e6
+	params := map[string]string{"customerId": "c1"}
+
+	r := signCallback(t, timestamp, "nonce1", "c1")
+	assert.Nil(t, v.Verify(HeadersFromRequest(r), params))
+	// 同一个nonce不能重复使用
+	assert.Equal(t, ErrNonceReused, v.Verify(HeadersFromRequest(r), params))
+
+	// 业务参数被篡改
+	r = signCallback(t, timestamp, "nonce2", "c1")
+	assert.Equal(t, ErrSignatureMismatch, v.Verify(HeadersFromRequest(r), map[string]string{"customerId": "c2"}))
+	// 签名失败的请求不占用nonce
+	assert.Nil(t, v.Verify(HeadersFromRequest(r), params))
+
+	r = signCallback(t, timestamp-2*time.Minute.Milliseconds(), "nonce3", "c1")
+	assert.Equal(t, ErrTimestampExpired, v.Verify(HeadersFromRequest(r), params))
+
+	r = signCallback(t, timestamp, "nonce4", "c1")
+	r.Header.Set("timestamp", "abc")
+	assert.True(t, errors.Is(v.Verify(HeadersFromRequest(r), params), ErrTimestampExpired))
+
+	r = signCallback(t, timestamp, "nonce5", "c1")
+	r.Header.Set("appKey", "other")
+	assert.Equal(t, ErrAppKeyMismatch, v.Verify(HeadersFromRequest(r), params))
+
+	r = signCallback(t, timestamp, "nonce6", "c1")
+	assert.Equal(t, ErrSignatureMismatch, v.Verify(HeadersFromRequest(r), map[string]string{"customerId": "c1", "unknown": "x"}))
+
+	r = signCallback(t, timestamp, "", "c1")
+	assert.Equal(t, ErrNonceMissing, v.Verify(HeadersFromRequest(r), params))
+}
+
+func TestVerifierSM3(t *testing.T) {
+	now := time.Unix(1650000000, 0)
+	timestamp := now.UnixNano() / 1e6
+	params := map[string]string{"customerId": "c1"}
+	signature, err := NewSignatureWithAlgorithm(HMACSM3, "appKey", "appSecret", timestamp, "1.0.0", "nonce1", params).GetSignature()
+	assert.Nil(t, err)
+	headers := CallbackHeaders{AppKey: "appKey", Timestamp: strconv.FormatInt(timestamp, 10), Version: "1.0.0", XReqNonce: "nonce1", Signature: signature}
+
+	v := NewVerifier("appKey", "appSecret", time.Minute, cache.NewMemory())
+	v.now = func() time.Time {
+		return now
+	}
+	assert.Equal(t, ErrSignatureMismatch, v.Verify(headers, params))
+
+	v = NewVerifierWithAlgorithm(HMACSM3, "appKey", "appSecret", time.Minute, cache.NewMemory())
+	v.now = func() time.Time {
+		return now
+	}
+	assert.Nil(t, v.Verify(headers, params))
+
+	v = NewVerifierWithAlgorithm("HMAC-MD5", "appKey", "appSecret", time.Minute, cache.NewMemory())
+	v.now = func() time.Time {
+		return now
+	}
+	headers.XReqNonce = "nonce2"
+	assert.NotNil(t, v.Verify(headers, params))
+}
+
+func TestVerifierReplayConcurrent(t *testing.T) {
+	now := time.Unix(1650000000, 0)
+	timestamp := now.UnixNano() / 1e6
+	params := map[string]string{"customerId": "c1"}
+	headers := HeadersFromRequest(signCallback(t, timestamp, "nonce1", "c1"))
+
+	// 多个实例共享同一个缓存，同时收到重放的请求
+	shared := cache.NewMemory()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var passed int
+	for i := 0; i < 20; i++ {
+		v := NewVerifier("appKey", "appSecret", time.Minute, shared)
+		v.now = func() time.Time {
+			return now
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v.Verify(headers, params) == nil {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, passed)
+
+	// 未实现cache.Adder的缓存仍可使用
+	v := NewVerifier("appKey", "appSecret", time.Minute, failingCache{cache.NewMemory()})
+	v.now = func() time.Time {
+		return now
+	}
+	assert.EqualError(t, v.Verify(headers, params), "cache unavailable")
+}
@@ -1,28 +1,16 @@
 package finance
 
 import (
-	log "github.com/sirupsen/logrus"
 	"github.com/yangzhenrui/finance/cache"
+	"github.com/yangzhenrui/finance/logger"
 	"github.com/yangzhenrui/finance/yiqiying"
 	yiqiyingConfig "github.com/yangzhenrui/finance/yiqiying/config"
-	"os"
 )
 
-func init() {
-	// Log as JSON instead of the default ASCII formatter.
-	log.SetFormatter(&log.TextFormatter{})
-
-	// Output to stdout instead of the default stderr
-	// Can be any io.Writer, see below for File example
-	log.SetOutput(os.Stdout)
-
-	// Only log the warning severity or above.
-	log.SetLevel(log.DebugLevel)
-}
-
 // Finance struct
 type Finance struct {
-	cache cache.Cache
+	cache  cache.Cache
+	logger logger.Logger
 }
 
 // NewFinance init
@@ -35,10 +23,18 @@ func (f *Finance) SetCache(cahce cache.Cache) {
 	f.cache = cahce
 }
 
+// SetLogger 设置请求日志，未设置时不输出日志
+func (f *Finance) SetLogger(l logger.Logger) {
+	f.logger = l
+}
+
 // GetYiQiYing 获取亿企赢的实例
 func (f *Finance) GetYiQiYing(cfg *yiqiyingConfig.Config) *yiqiying.YiQiYing {
 	if cfg.Cache == nil {
 		cfg.Cache = f.cache
 	}
+	if cfg.Logger == nil {
+		cfg.Logger = f.logger
+	}
 	return yiqiying.NewYiQiYing(cfg)
 }
@@ -0,0 +1,78 @@
+// Package logger 可插拔的结构化日志接口，默认不输出任何日志
+package logger
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields 结构化日志字段
+type Fields map[string]interface{}
+
+// Logger 日志接口
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Error(msg string, fields Fields)
+}
+
+// RedactedHeaders 日志中需要脱敏的请求头(不区分大小写)
+var RedactedHeaders = []string{"signature", "appSecret", "Authorization"}
+
+// redacted 脱敏后的占位符
+const redacted = "******"
+
+// Nop 不输出任何日志
+type Nop struct{}
+
+// Debug 忽略
+func (Nop) Debug(string, Fields) {}
+
+// Info 忽略
+func (Nop) Info(string, Fields) {}
+
+// Error 忽略
+func (Nop) Error(string, Fields) {}
+
+// Logrus 使用logrus输出日志
+type Logrus struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrus 实例化logrus日志，l为nil时使用logrus的标准logger
+func NewLogrus(l logrus.FieldLogger) *Logrus {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	return &Logrus{logger: l}
+}
+
+// Debug debug日志
+func (l *Logrus) Debug(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Debug(msg)
+}
+
+// Info info日志
+func (l *Logrus) Info(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Info(msg)
+}
+
+// Error error日志
+func (l *Logrus) Error(msg string, fields Fields) {
+	l.logger.WithFields(logrus.Fields(fields)).Error(msg)
+}
+
+// RedactHeader 复制请求头并将RedactedHeaders中的值替换为占位符
+func RedactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for key := range result {
+		for _, name := range RedactedHeaders {
+			if strings.EqualFold(key, name) {
+				result[key] = []string{redacted}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("signature", "c2lnbmF0dXJl")
+	header.Set("appKey", "appKey")
+	header["appSecret"] = []string{"secret"}
+
+	redactedHeader := RedactHeader(header)
+	assert.Equal(t, redacted, redactedHeader.Get("signature"))
+	assert.Equal(t, []string{redacted}, redactedHeader["appSecret"])
+	assert.Equal(t, "appKey", redactedHeader.Get("appKey"))
+	assert.Equal(t, "c2lnbmF0dXJl", header.Get("signature"))
+}
+
+func TestLogrus(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+
+	NewLogrus(l).Info("yiqiying request", Fields{"code": "00000000"})
+	assert.Contains(t, buf.String(), `"code":"00000000"`)
+	assert.Contains(t, buf.String(), `"msg":"yiqiying request"`)
+}
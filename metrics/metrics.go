@@ -0,0 +1,23 @@
+// Package metrics 网关调用指标，按服务和方法统计请求数、耗时、错误、重试及限流等待
+package metrics
+
+import (
+	"time"
+)
+
+// Collector 指标收集接口
+type Collector interface {
+	// ObserveRequest 记录一次网关请求，code为网关错误代码，成功时为空
+	ObserveRequest(service, operation string, latency time.Duration, code string, err error)
+
+	// ObserveRetry 记录一次重试
+	ObserveRetry(service, operation string)
+
+	// ObserveRateLimitWait 记录一次限流等待
+	ObserveRateLimitWait(service, operation string, wait time.Duration)
+}
+
+// ClockSkewObserver 可选接口，Collector实现后记录网关与本地的时钟偏差
+type ClockSkewObserver interface {
+	ObserveClockSkew(skew time.Duration)
+}
@@ -0,0 +1,112 @@
+// Package prometheus 以prometheus格式输出网关调用指标，实现metrics.Collector
+// 单独成包，未使用prometheus的程序不会引入client_golang依赖
+package prometheus
+
+import (
+	"net/http"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/yangzhenrui/finance/metrics"
+)
+
+const (
+	// Namespace 指标名前缀
+	Namespace = "yiqiying"
+)
+
+var (
+	_ metrics.Collector         = (*Collector)(nil)
+	_ metrics.ClockSkewObserver = (*Collector)(nil)
+)
+
+// Collector 以prometheus格式输出的指标
+type Collector struct {
+	registry      *prom.Registry
+	requests      *prom.CounterVec
+	latency       *prom.HistogramVec
+	errors        *prom.CounterVec
+	retries       *prom.CounterVec
+	rateLimitWait *prom.HistogramVec
+	clockSkew     prom.Gauge
+}
+
+// New 创建指标并注册到reg，reg为nil时使用新的Registry
+// Handler输出的是reg中的指标，因此要求*prom.Registry而非任意Registerer
+func New(reg *prom.Registry) (*Collector, error) {
+	if reg == nil {
+		reg = prom.NewRegistry()
+	}
+	labels := []string{"service", "operation"}
+	p := &Collector{
+		registry: reg,
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: Namespace,
+			Name:      "requests_total",
+			Help:      "网关请求次数",
+		}, labels),
+		latency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "request_duration_seconds",
+			Help:      "网关请求耗时",
+			Buckets:   prom.DefBuckets,
+		}, labels),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: Namespace,
+			Name:      "request_errors_total",
+			Help:      "网关请求失败次数，按错误代码统计",
+		}, append(labels, "code")),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: Namespace,
+			Name:      "retries_total",
+			Help:      "重试次数",
+		}, labels),
+		rateLimitWait: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "ratelimit_wait_seconds",
+			Help:      "客户端限流等待时间",
+			Buckets:   prom.DefBuckets,
+		}, labels),
+		clockSkew: prom.NewGauge(prom.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "clock_skew_seconds",
+			Help:      "网关时间与本地时间的偏差，网关较快时为正",
+		}),
+	}
+	for _, c := range []prom.Collector{p.requests, p.latency, p.errors, p.retries, p.rateLimitWait, p.clockSkew} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Handler 输出指标的http handler，可挂载到/metrics
+func (p *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest 记录一次网关请求
+func (p *Collector) ObserveRequest(service, operation string, latency time.Duration, code string, err error) {
+	p.requests.WithLabelValues(service, operation).Inc()
+	p.latency.WithLabelValues(service, operation).Observe(latency.Seconds())
+	if err != nil {
+		p.errors.WithLabelValues(service, operation, code).Inc()
+	}
+}
+
+// ObserveRetry 记录一次重试
+func (p *Collector) ObserveRetry(service, operation string) {
+	p.retries.WithLabelValues(service, operation).Inc()
+}
+
+// ObserveRateLimitWait 记录一次限流等待
+func (p *Collector) ObserveRateLimitWait(service, operation string, wait time.Duration) {
+	p.rateLimitWait.WithLabelValues(service, operation).Observe(wait.Seconds())
+}
+
+// ObserveClockSkew 记录时钟偏差
+func (p *Collector) ObserveClockSkew(skew time.Duration) {
+	p.clockSkew.Set(skew.Seconds())
+}
@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	p, err := New(nil)
+	assert.Nil(t, err)
+
+	p.ObserveRequest("Tax", "GetTaxList", 20*time.Millisecond, "", nil)
+	p.ObserveRequest("Tax", "GetTaxList", 30*time.Millisecond, "10000001", errors.New("签名错误"))
+	p.ObserveRetry("Tax", "GetTaxList")
+	p.ObserveRateLimitWait("Tax", "GetTaxList", 5*time.Millisecond)
+	p.ObserveClockSkew(-1500 * time.Millisecond)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(p.requests.WithLabelValues("Tax", "GetTaxList")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(p.errors.WithLabelValues("Tax", "GetTaxList", "10000001")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(p.retries.WithLabelValues("Tax", "GetTaxList")))
+	assert.Equal(t, -1.5, testutil.ToFloat64(p.clockSkew))
+
+	recorder := httptest.NewRecorder()
+	p.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Contains(t, string(body), `yiqiying_request_duration_seconds_count{operation="GetTaxList",service="Tax"} 2`)
+	assert.Contains(t, string(body), `yiqiying_ratelimit_wait_seconds_count{operation="GetTaxList",service="Tax"} 1`)
+}
+
+func TestCollectorRegisterTwice(t *testing.T) {
+	reg := prom.NewRegistry()
+	_, err := New(reg)
+	assert.Nil(t, err)
+	_, err = New(reg)
+	assert.NotNil(t, err)
+}
+
+func TestCollectorHandlerRegistry(t *testing.T) {
+	reg := prom.NewRegistry()
+	p, err := New(reg)
+	assert.Nil(t, err)
+	p.ObserveRetry("Tax", "GetTaxList")
+
+	recorder := httptest.NewRecorder()
+	p.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Contains(t, string(body), `yiqiying_retries_total{operation="GetTaxList",service="Tax"} 1`)
+	// 不混入DefaultGatherer中的进程指标
+	assert.NotContains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,47 @@
+// Package ratelimit 客户端限流，按appKey共享请求额度
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limiter 限流接口，key通常为appKey
+type Limiter interface {
+	// Wait 阻塞直到获得一个请求额度或ctx结束
+	Wait(ctx context.Context, key string) error
+}
+
+// LimitSetter 可按key单独设置额度的Limiter
+type LimitSetter interface {
+	SetLimit(key string, limit Limit)
+}
+
+// Limit 令牌桶参数
+type Limit struct {
+	QPS   float64 `json:"qps"`   // 每秒产生的令牌数，小于等于0时不限流
+	Burst int     `json:"burst"` // 桶容量，允许的突发请求数，最小为1
+}
+
+// burst 桶容量
+func (l Limit) burst() float64 {
+	if l.Burst < 1 {
+		return 1
+	}
+	return float64(l.Burst)
+}
+
+// sleep 等待d或ctx结束
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/yangzhenrui/finance/cache"
+)
+
+const (
+	// CacheKeyRateLimitPrefix 限流令牌桶的redis key前缀
+	CacheKeyRateLimitPrefix = "go_yiqiying_ratelimit_"
+)
+
+// tokenBucketScript 在redis中原子地补充并取走一个令牌，返回需要等待的微秒数
+// 使用redis服务器时间，避免多个实例之间的时钟差异
+var tokenBucketScript = redis.NewScript(1, `
+if redis.replicate_commands then pcall(redis.replicate_commands) end
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(burst, tokens + (now - ts) * rate / 1000000)
+	ts = now
+end
+tokens = tokens - 1
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+if tokens >= 0 then
+	return 0
+end
+return math.ceil(-tokens / rate * 1000000)
+`)
+
+// refundScript 等待被取消时归还令牌
+var refundScript = redis.NewScript(1, `
+local tokens = tonumber(redis.call('HGET', KEYS[1], 'tokens'))
+if tokens then
+	redis.call('HSET', KEYS[1], 'tokens', tostring(math.min(tonumber(ARGV[1]), tokens + 1)))
+end
+return 0
+`)
+
+// RedisTokenBucket 基于redis的令牌桶，多个实例共享同一份额度
+// redis不可用时默认退化为进程内令牌桶，各实例按相同额度各自限流，总请求量可能超出额度，
+// 但不会因为限流组件故障导致网关请求失败
+type RedisTokenBucket struct {
+	mu       sync.RWMutex
+	redis    *cache.Redis
+	limit    Limit
+	limits   map[string]Limit
+	fallback Limiter
+}
+
+// NewRedisTokenBucket 实例化redis令牌桶，limit为各appKey的默认额度
+func NewRedisTokenBucket(r *cache.Redis, limit Limit) *RedisTokenBucket {
+	return &RedisTokenBucket{
+		redis:    r,
+		limit:    limit,
+		limits:   map[string]Limit{},
+		fallback: NewTokenBucket(limit),
+	}
+}
+
+// SetLimit 单独设置某个appKey的额度，同时设置到实现了LimitSetter的fallback
+func (rb *RedisTokenBucket) SetLimit(key string, limit Limit) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	rb.limits[key] = limit
+	if setter, ok := rb.fallback.(LimitSetter); ok {
+		setter.SetLimit(key, limit)
+	}
+}
+
+// SetFallback 设置redis不可用时使用的限流，为nil时直接返回redis的错误，即请求失败
+func (rb *RedisTokenBucket) SetFallback(fallback Limiter) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	rb.fallback = fallback
+}
+
+// Wait 阻塞直到获得一个请求额度或ctx结束
+func (rb *RedisTokenBucket) Wait(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	limit := rb.getLimit(key)
+	if limit.QPS <= 0 {
+		return nil
+	}
+
+	cacheKey := CacheKeyRateLimitPrefix + key
+	wait, err := redis.Int64(rb.do(tokenBucketScript, cacheKey, limit.QPS, limit.burst()))
+	if err != nil {
+		if fallback := rb.getFallback(); fallback != nil {
+			return fallback.Wait(ctx, key)
+		}
+		return err
+	}
+	if err = sleep(ctx, time.Duration(wait)*time.Microsecond); err != nil {
+		_, _ = rb.do(refundScript, cacheKey, limit.burst())
+		return err
+	}
+	return nil
+}
+
+// do 执行脚本，连接不在等待期间占用
+func (rb *RedisTokenBucket) do(script *redis.Script, keysAndArgs ...interface{}) (interface{}, error) {
+	conn := rb.redis.GetRedisPool().Get()
+	defer conn.Close()
+
+	return script.Do(conn, keysAndArgs...)
+}
+
+func (rb *RedisTokenBucket) getFallback() Limiter {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	return rb.fallback
+}
+
+func (rb *RedisTokenBucket) getLimit(key string) Limit {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+
+	if limit, ok := rb.limits[key]; ok {
+		return limit
+	}
+	return rb.limit
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+)
+
+func TestRedisTokenBucket(t *testing.T) {
+	r := cache.NewRedis(&cache.RedisOpts{
+		Host: "127.0.0.1:6379",
+	})
+	conn := r.GetRedisPool().Get()
+	_, err := conn.Do("PING")
+	conn.Close()
+	if err != nil {
+		t.Skipf("redis is not available: %v", err)
+	}
+
+	rb := NewRedisTokenBucket(r, Limit{QPS: 20, Burst: 1})
+	key := "test_" + time.Now().Format("150405.000000")
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, rb.Wait(context.Background(), key))
+	}
+	assert.True(t, time.Since(start) >= 80*time.Millisecond)
+}
+
+func TestRedisTokenBucketFallback(t *testing.T) {
+	// 没有服务监听的端口，redis调用必然失败
+	r := cache.NewRedis(&cache.RedisOpts{
+		Host: "127.0.0.1:1",
+	})
+	rb := NewRedisTokenBucket(r, Limit{QPS: 20, Burst: 1})
+	rb.SetLimit("unlimited", Limit{})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, rb.Wait(context.Background(), "appKey"))
+	}
+	assert.True(t, time.Since(start) >= 80*time.Millisecond)
+	assert.Nil(t, rb.Wait(context.Background(), "unlimited"))
+
+	rb.SetFallback(nil)
+	assert.NotNil(t, rb.Wait(context.Background(), "appKey"))
+}
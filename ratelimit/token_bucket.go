@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBucket 进程内的令牌桶限流
+type TokenBucket struct {
+	mu      sync.Mutex
+	limit   Limit
+	limits  map[string]Limit
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket 实例化进程内令牌桶，limit为各appKey的默认额度
+func NewTokenBucket(limit Limit) *TokenBucket {
+	return &TokenBucket{
+		limit:   limit,
+		limits:  map[string]Limit{},
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// SetLimit 单独设置某个appKey的额度，额度不变时保留已有的令牌
+func (tb *TokenBucket) SetLimit(key string, limit Limit) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if old, ok := tb.limits[key]; ok && old == limit {
+		return
+	}
+	tb.limits[key] = limit
+	delete(tb.buckets, key)
+}
+
+// Wait 阻塞直到获得一个请求额度或ctx结束
+func (tb *TokenBucket) Wait(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wait := tb.reserve(key)
+	if err := sleep(ctx, wait); err != nil {
+		tb.cancel(key)
+		return err
+	}
+	return nil
+}
+
+// reserve 取走一个令牌，返回令牌可用前需要等待的时间
+func (tb *TokenBucket) reserve(key string) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	limit := tb.getLimit(key)
+	if limit.QPS <= 0 {
+		return 0
+	}
+
+	now := tb.now()
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), last: now}
+		tb.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(limit.burst(), b.tokens+elapsed.Seconds()*limit.QPS)
+		b.last = now
+	}
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / limit.QPS * float64(time.Second))
+}
+
+// cancel 等待被取消时归还令牌
+func (tb *TokenBucket) cancel(key string) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if b, ok := tb.buckets[key]; ok {
+		b.tokens = math.Min(tb.getLimit(key).burst(), b.tokens+1)
+	}
+}
+
+// getLimit 调用方需持有锁
+func (tb *TokenBucket) getLimit(key string) Limit {
+	if limit, ok := tb.limits[key]; ok {
+		return limit
+	}
+	return tb.limit
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	now := time.Unix(1650000000, 0)
+	tb := NewTokenBucket(Limit{QPS: 10, Burst: 2})
+	tb.now = func() time.Time {
+		return now
+	}
+
+	assert.Equal(t, time.Duration(0), tb.reserve("a"))
+	assert.Equal(t, time.Duration(0), tb.reserve("a"))
+	assert.Equal(t, 100*time.Millisecond, tb.reserve("a"))
+
+	// 不同appKey的额度互不影响
+	assert.Equal(t, time.Duration(0), tb.reserve("b"))
+
+	now = now.Add(300 * time.Millisecond)
+	assert.Equal(t, time.Duration(0), tb.reserve("a"))
+
+	tb.SetLimit("c", Limit{})
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, time.Duration(0), tb.reserve("c"))
+	}
+
+	// 重复设置相同额度不重置已消耗的令牌
+	tb.SetLimit("d", Limit{QPS: 10, Burst: 1})
+	assert.Equal(t, time.Duration(0), tb.reserve("d"))
+	tb.SetLimit("d", Limit{QPS: 10, Burst: 1})
+	assert.Equal(t, 100*time.Millisecond, tb.reserve("d"))
+	tb.SetLimit("d", Limit{QPS: 20, Burst: 1})
+	assert.Equal(t, time.Duration(0), tb.reserve("d"))
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	tb := NewTokenBucket(Limit{QPS: 100, Burst: 1})
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, tb.Wait(context.Background(), "a"))
+		}()
+	}
+	wg.Wait()
+	assert.True(t, time.Since(start) >= 35*time.Millisecond)
+
+	tb = NewTokenBucket(Limit{QPS: 1, Burst: 1})
+	assert.Nil(t, tb.Wait(context.Background(), "a"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, tb.Wait(ctx, "a"))
+}
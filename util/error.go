@@ -1,5 +1,12 @@
 package util
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
 // CommonError 亿企赢返回的通用错误json
 type CommonError struct {
 	Code        string `json:"code"`
@@ -8,3 +15,179 @@ type CommonError struct {
 	Time        string `json:"time"`
 	Status      string `json:"status"`
 }
+
+// ErrorKind 网关错误的类别
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown 未归类的错误
+	ErrorKindUnknown ErrorKind = iota
+
+	// ErrorKindSignature 签名校验失败
+	ErrorKindSignature
+
+	// ErrorKindRateLimited 请求被网关限流
+	ErrorKindRateLimited
+
+	// ErrorKindNotFound 客户、账套等资源不存在
+	ErrorKindNotFound
+)
+
+// ErrorClassifier 根据http状态码及网关返回的head判断错误类别
+// 网关通常以http 200返回错误代码，代码匹配优先，其次在msg、description中查找关键字
+type ErrorClassifier struct {
+	SignatureCodes      []string
+	RateLimitedCodes    []string
+	NotFoundCodes       []string
+	SignatureKeywords   []string
+	RateLimitedKeywords []string
+	NotFoundKeywords    []string
+}
+
+// DefaultErrorClassifier 默认的错误分类，按网关错误描述中的关键字判断
+// 每次返回新的实例，可在此基础上补充错误代码后设置到config.Config
+func DefaultErrorClassifier() *ErrorClassifier {
+	return &ErrorClassifier{
+		SignatureKeywords:   []string{"签名", "signature"},
+		RateLimitedKeywords: []string{"限流", "频繁", "too many requests"},
+		NotFoundKeywords:    []string{"不存在", "查无", "not found"},
+	}
+}
+
+// Classify 判断错误类别，head为nil表示响应无法解析
+func (c *ErrorClassifier) Classify(statusCode int, head *CommonError) ErrorKind {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ErrorKindSignature
+	case http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case http.StatusNotFound:
+		return ErrorKindNotFound
+	}
+	if head == nil || head.Code == "" {
+		return ErrorKindUnknown
+	}
+
+	switch {
+	case containsCode(c.SignatureCodes, head.Code):
+		return ErrorKindSignature
+	case containsCode(c.RateLimitedCodes, head.Code):
+		return ErrorKindRateLimited
+	case containsCode(c.NotFoundCodes, head.Code):
+		return ErrorKindNotFound
+	}
+
+	text := strings.ToLower(head.Msg + " " + head.Description)
+	switch {
+	case containsKeyword(text, c.SignatureKeywords):
+		return ErrorKindSignature
+	case containsKeyword(text, c.RateLimitedKeywords):
+		return ErrorKindRateLimited
+	case containsKeyword(text, c.NotFoundKeywords):
+		return ErrorKindNotFound
+	}
+	return ErrorKindUnknown
+}
+
+// APIError 亿企赢接口调用失败时返回的错误，可通过errors.As获取
+type APIError struct {
+	CommonError
+	Message    string    // 调用的接口描述
+	StatusCode int       // http状态码
+	URL        string    // 请求地址
+	XReqNonce  string    // 本次请求的xReqNonce，用于和网关日志对照
+	Kind       ErrorKind // 错误类别，未经NewAPIError分类且为ErrorKindUnknown时按DefaultErrorClassifier判断
+	Err        error     // 响应无法解析时的原始错误
+
+	classified bool // 是否已由NewAPIError按配置的classifier分类
+}
+
+// NewAPIError 实例化，按classifier判断错误类别，classifier为nil时使用DefaultErrorClassifier
+func NewAPIError(message string, statusCode int, head *CommonError, classifier *ErrorClassifier) *APIError {
+	if classifier == nil {
+		classifier = DefaultErrorClassifier()
+	}
+	apiErr := &APIError{
+		Message:    message,
+		StatusCode: statusCode,
+		Kind:       classifier.Classify(statusCode, head),
+		classified: true,
+	}
+	if head != nil {
+		apiErr.CommonError = *head
+	}
+	return apiErr
+}
+
+// Error 错误信息
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s,http状态码(%d),%v", e.Message, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("%s,%v,%v 出错代码为(%v)", e.Message, e.Msg, e.Description, e.Code)
+}
+
+// Unwrap 返回原始错误
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// AsAPIError 从err中取出*APIError
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsSignatureError 是否为签名校验失败
+func IsSignatureError(err error) bool {
+	return errorKind(err) == ErrorKindSignature
+}
+
+// IsRateLimited 是否被网关限流
+func IsRateLimited(err error) bool {
+	return errorKind(err) == ErrorKindRateLimited
+}
+
+// IsNotFound 是否为资源不存在
+func IsNotFound(err error) bool {
+	return errorKind(err) == ErrorKindNotFound
+}
+
+// errorKind 取出err的错误类别，手动构造且未设置Kind的APIError按DefaultErrorClassifier判断
+func errorKind(err error) ErrorKind {
+	apiErr, ok := AsAPIError(err)
+	if !ok {
+		return ErrorKindUnknown
+	}
+	if apiErr.classified || apiErr.Kind != ErrorKindUnknown {
+		return apiErr.Kind
+	}
+	var head *CommonError
+	if apiErr.Code != "" {
+		head = &apiErr.CommonError
+	}
+	return DefaultErrorClassifier().Classify(apiErr.StatusCode, head)
+}
+
+// containsCode code是否在codes中
+func containsCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// containsKeyword text是否包含任一关键字，text需已转为小写
+func containsKeyword(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	apiErr := &APIError{
+		CommonError: CommonError{Code: "10000001", Msg: "签名错误", Description: "signature invalid", Status: "N"},
+		Message:     "查询税种信息出错",
+		StatusCode:  http.StatusOK,
+	}
+	assert.Equal(t, "查询税种信息出错,签名错误,signature invalid 出错代码为(10000001)", apiErr.Error())
+
+	wrapped := fmt.Errorf("sync customer: %w", apiErr)
+	got, ok := AsAPIError(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, "10000001", got.Code)
+
+	assert.True(t, IsSignatureError(wrapped))
+	assert.False(t, IsRateLimited(wrapped))
+	assert.False(t, IsNotFound(wrapped))
+
+	assert.True(t, IsRateLimited(&APIError{StatusCode: http.StatusTooManyRequests}))
+	assert.True(t, IsNotFound(&APIError{StatusCode: http.StatusNotFound}))
+	assert.False(t, IsNotFound(errors.New("not found")))
+
+	cause := errors.New("unexpected end of JSON input")
+	apiErr = &APIError{Message: "利润表数据出错", StatusCode: http.StatusBadGateway, Err: cause}
+	assert.Equal(t, "利润表数据出错,http状态码(502),unexpected end of JSON input", apiErr.Error())
+	assert.True(t, errors.Is(apiErr, cause))
+}
+
+func TestErrorClassifier(t *testing.T) {
+	classifier := DefaultErrorClassifier()
+	assert.Equal(t, ErrorKindSignature, classifier.Classify(http.StatusOK, &CommonError{Code: "10000001", Msg: "签名错误"}))
+	assert.Equal(t, ErrorKindRateLimited, classifier.Classify(http.StatusOK, &CommonError{Code: "20000001", Msg: "请求过于频繁"}))
+	assert.Equal(t, ErrorKindNotFound, classifier.Classify(http.StatusOK, &CommonError{Code: "30000001", Description: "客户不存在"}))
+	assert.Equal(t, ErrorKindUnknown, classifier.Classify(http.StatusOK, &CommonError{Code: "40000001", Msg: "参数错误"}))
+	assert.Equal(t, ErrorKindUnknown, classifier.Classify(http.StatusBadGateway, nil))
+	assert.Equal(t, ErrorKindSignature, classifier.Classify(http.StatusUnauthorized, nil))
+
+	classifier.NotFoundCodes = []string{"40000001"}
+	apiErr := NewAPIError("查询客户出错", http.StatusOK, &CommonError{Code: "40000001", Msg: "参数错误"}, classifier)
+	assert.True(t, IsNotFound(apiErr))
+	assert.Equal(t, "40000001", apiErr.Code)
+	assert.False(t, IsNotFound(NewAPIError("查询客户出错", http.StatusOK, &CommonError{Code: "40000001", Msg: "参数错误"}, nil)))
+
+	// 已按配置分类的错误不再使用默认关键字
+	classifier = &ErrorClassifier{SignatureCodes: []string{"10000001"}}
+	apiErr = NewAPIError("查询客户出错", http.StatusOK, &CommonError{Code: "20000001", Msg: "签名参数customerId不存在"}, classifier)
+	assert.False(t, IsSignatureError(apiErr))
+	assert.False(t, IsNotFound(apiErr))
+	assert.True(t, IsSignatureError(NewAPIError("查询客户出错", http.StatusOK, &CommonError{Code: "10000001"}, classifier)))
+	assert.Empty(t, DefaultErrorClassifier().NotFoundCodes)
+}
@@ -1,36 +1,123 @@
 package util
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/ioutil"
 )
 
-// RSADecrypt 数据解密
-func RSADecrypt(privateKey string, ciphertext []byte) ([]byte, error) {
+// pkcs1v15Overhead PKCS#1 v1.5填充占用的字节数
+const pkcs1v15Overhead = 11
+
+// ParseRSAPrivateKey 解析PEM格式的RSA私钥，支持PKCS#1与PKCS#8
+func ParseRSAPrivateKey(privateKey string) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode([]byte(privateKey))
 	if block == nil {
 		return nil, errors.New("PrivateKey format error")
 	}
-	priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	return ParseRSAPrivateKeyDER(block.Bytes)
+}
+
+// ParseRSAPrivateKeyDER 解析DER格式的RSA私钥，支持PKCS#1与PKCS#8
+func ParseRSAPrivateKeyDER(der []byte) (*rsa.PrivateKey, error) {
+	priv, err := x509.ParsePKCS1PrivateKey(der)
+	if err == nil {
+		return priv, nil
+	}
+	oldErr := err
+	key, err := x509.ParsePKCS8PrivateKey(der)
 	if err != nil {
-		oldErr := err
-		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		return nil, fmt.Errorf("ParsePKCS1PrivateKey error: %s, ParsePKCS8PrivateKey error: %s", oldErr.Error(), err.Error())
+	}
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("ParsePKCS1PrivateKey error: %s, ParsePKCS8PrivateKey error: Not supported privatekey format, should be *rsa.PrivateKey, got %T", oldErr.Error(), key)
+	}
+	return priv, nil
+}
+
+// ParseRSAPublicKey 解析PEM格式的RSA公钥，支持PKIX、PKCS#1及证书
+func ParseRSAPublicKey(publicKey string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, errors.New("PublicKey format error")
+	}
+	if block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
 		if err != nil {
-			return nil, fmt.Errorf("ParsePKCS1PrivateKey error: %s, ParsePKCS8PrivateKey error: %s", oldErr.Error(), err.Error())
+			return nil, err
+		}
+		pub, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("Not supported publickey format, should be *rsa.PublicKey, got %T", cert.PublicKey)
 		}
-		switch t := key.(type) {
-		case *rsa.PrivateKey:
-			priv = key.(*rsa.PrivateKey)
-		default:
-			return nil, fmt.Errorf("ParsePKCS1PrivateKey error: %s, ParsePKCS8PrivateKey error: Not supported privatekey format, should be *rsa.PrivateKey, got %T", oldErr.Error(), t)
+		return pub, nil
+	}
+	return ParseRSAPublicKeyDER(block.Bytes)
+}
+
+// ParseRSAPublicKeyDER 解析DER格式的RSA公钥，支持PKIX与PKCS#1
+func ParseRSAPublicKeyDER(der []byte) (*rsa.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		oldErr := err
+		pub, err := x509.ParsePKCS1PublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("ParsePKIXPublicKey error: %s, ParsePKCS1PublicKey error: %s", oldErr.Error(), err.Error())
 		}
+		return pub, nil
+	}
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("Not supported publickey format, should be *rsa.PublicKey, got %T", key)
+	}
+	return pub, nil
+}
+
+// LoadRSAPrivateKey 从文件加载RSA私钥，文件可以是PEM或DER格式
+func LoadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParseRSAPrivateKey(string(data))
+	}
+	return ParseRSAPrivateKeyDER(data)
+}
+
+// LoadRSAPublicKey 从文件加载RSA公钥，文件可以是PEM或DER格式
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if isPEM(data) {
+		return ParseRSAPublicKey(string(data))
+	}
+	return ParseRSAPublicKeyDER(data)
+}
+
+// isPEM 是否为PEM格式
+func isPEM(data []byte) bool {
+	return bytes.Contains(data, []byte("-----BEGIN "))
+}
+
+// RSADecrypt 数据解密，密文长度超过密钥长度时按密钥长度分段解密
+func RSADecrypt(privateKey string, ciphertext []byte) ([]byte, error) {
+	priv, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
 	}
-	return rsa.DecryptPKCS1v15(rand.Reader, priv, ciphertext)
+	return RSADecryptWithKey(priv, ciphertext)
 }
 
 // RSADecryptBase64 Base64解码后再次进行RSA解密
@@ -41,3 +128,169 @@ func RSADecryptBase64(privateKey string, cryptoText string) ([]byte, error) {
 	}
 	return RSADecrypt(privateKey, encryptedData)
 }
+
+// RSADecryptWithKey PKCS#1 v1.5分段解密
+func RSADecryptWithKey(priv *rsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	return decryptBlocks(priv.Size(), ciphertext, func(block []byte) ([]byte, error) {
+		return rsa.DecryptPKCS1v15(rand.Reader, priv, block)
+	})
+}
+
+// RSAEncrypt 数据加密，明文超过单次可加密的长度时分段加密
+func RSAEncrypt(publicKey string, plaintext []byte) ([]byte, error) {
+	pub, err := ParseRSAPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return RSAEncryptWithKey(pub, plaintext)
+}
+
+// RSAEncryptBase64 RSA加密后进行Base64编码
+func RSAEncryptBase64(publicKey string, plaintext []byte) (string, error) {
+	encryptedData, err := RSAEncrypt(publicKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encryptedData), nil
+}
+
+// RSAEncryptWithKey PKCS#1 v1.5分段加密，每段最多为密钥长度减11字节
+func RSAEncryptWithKey(pub *rsa.PublicKey, plaintext []byte) ([]byte, error) {
+	return encryptBlocks(pub.Size()-pkcs1v15Overhead, plaintext, func(block []byte) ([]byte, error) {
+		return rsa.EncryptPKCS1v15(rand.Reader, pub, block)
+	})
+}
+
+// RSAEncryptOAEP OAEP(SHA256)数据加密，明文超过单次可加密的长度时分段加密
+func RSAEncryptOAEP(publicKey string, plaintext []byte) ([]byte, error) {
+	pub, err := ParseRSAPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return RSAEncryptOAEPWithKey(pub, plaintext)
+}
+
+// RSAEncryptOAEPBase64 OAEP(SHA256)加密后进行Base64编码
+func RSAEncryptOAEPBase64(publicKey string, plaintext []byte) (string, error) {
+	encryptedData, err := RSAEncryptOAEP(publicKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(encryptedData), nil
+}
+
+// RSAEncryptOAEPWithKey OAEP(SHA256)分段加密，每段最多为密钥长度减66字节
+func RSAEncryptOAEPWithKey(pub *rsa.PublicKey, plaintext []byte) ([]byte, error) {
+	return encryptBlocks(pub.Size()-2*sha256.Size-2, plaintext, func(block []byte) ([]byte, error) {
+		return rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, block, nil)
+	})
+}
+
+// RSADecryptOAEP OAEP(SHA256)数据解密，密文长度超过密钥长度时按密钥长度分段解密
+func RSADecryptOAEP(privateKey string, ciphertext []byte) ([]byte, error) {
+	priv, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return RSADecryptOAEPWithKey(priv, ciphertext)
+}
+
+// RSADecryptOAEPBase64 Base64解码后再进行OAEP(SHA256)解密
+func RSADecryptOAEPBase64(privateKey string, cryptoText string) ([]byte, error) {
+	encryptedData, err := base64.StdEncoding.DecodeString(cryptoText)
+	if err != nil {
+		return nil, err
+	}
+	return RSADecryptOAEP(privateKey, encryptedData)
+}
+
+// RSADecryptOAEPWithKey OAEP(SHA256)分段解密
+func RSADecryptOAEPWithKey(priv *rsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	return decryptBlocks(priv.Size(), ciphertext, func(block []byte) ([]byte, error) {
+		return rsa.DecryptOAEP(sha256.New(), rand.Reader, priv, block, nil)
+	})
+}
+
+// RSASignSHA256 SHA256withRSA签名
+func RSASignSHA256(privateKey string, data []byte) ([]byte, error) {
+	priv, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return RSASignSHA256WithKey(priv, data)
+}
+
+// RSASignSHA256Base64 SHA256withRSA签名后进行Base64编码
+func RSASignSHA256Base64(privateKey string, data []byte) (string, error) {
+	signature, err := RSASignSHA256(privateKey, data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// RSASignSHA256WithKey SHA256withRSA签名
+func RSASignSHA256WithKey(priv *rsa.PrivateKey, data []byte) ([]byte, error) {
+	hashed := sha256.Sum256(data)
+	return rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+}
+
+// RSAVerifySHA256 校验SHA256withRSA签名
+func RSAVerifySHA256(publicKey string, data []byte, signature []byte) error {
+	pub, err := ParseRSAPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	return RSAVerifySHA256WithKey(pub, data, signature)
+}
+
+// RSAVerifySHA256Base64 Base64解码签名后校验SHA256withRSA签名
+func RSAVerifySHA256Base64(publicKey string, data []byte, signature string) error {
+	signatureData, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	return RSAVerifySHA256(publicKey, data, signatureData)
+}
+
+// RSAVerifySHA256WithKey 校验SHA256withRSA签名
+func RSAVerifySHA256WithKey(pub *rsa.PublicKey, data []byte, signature []byte) error {
+	hashed := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], signature)
+}
+
+// encryptBlocks 按blockSize切分明文后逐段加密并拼接
+func encryptBlocks(blockSize int, plaintext []byte, encrypt func([]byte) ([]byte, error)) ([]byte, error) {
+	if blockSize <= 0 {
+		return nil, errors.New("RSA key too short")
+	}
+	var buf bytes.Buffer
+	for start := 0; start < len(plaintext) || start == 0; start += blockSize {
+		end := start + blockSize
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+		block, err := encrypt(plaintext[start:end])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(block)
+	}
+	return buf.Bytes(), nil
+}
+
+// decryptBlocks 按密钥长度切分密文后逐段解密并拼接
+func decryptBlocks(keySize int, ciphertext []byte, decrypt func([]byte) ([]byte, error)) ([]byte, error) {
+	if len(ciphertext) == 0 || len(ciphertext)%keySize != 0 {
+		return nil, fmt.Errorf("RSA ciphertext length %d is not a multiple of key size %d", len(ciphertext), keySize)
+	}
+	var buf bytes.Buffer
+	for start := 0; start < len(ciphertext); start += keySize {
+		block, err := decrypt(ciphertext[start : start+keySize])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(block)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,206 @@
+package util
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/sm4"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// sm2MinCiphertextLen SM2密文至少包含0x04、C1(64字节)与C3(32字节)
+const sm2MinCiphertextLen = 1 + 64 + 32
+
+// ParseSM2PrivateKey 解析PEM格式(PKCS#8)的SM2私钥
+func ParseSM2PrivateKey(privateKey string) (*sm2.PrivateKey, error) {
+	priv, err := x509.ReadPrivateKeyFromPem([]byte(privateKey), nil)
+	if err != nil {
+		return nil, fmt.Errorf("SM2 PrivateKey format error: %w", err)
+	}
+	return priv, nil
+}
+
+// ParseSM2PublicKey 解析PEM格式的SM2公钥
+func ParseSM2PublicKey(publicKey string) (*sm2.PublicKey, error) {
+	pub, err := x509.ReadPublicKeyFromPem([]byte(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("SM2 PublicKey format error: %w", err)
+	}
+	if pub.X == nil {
+		return nil, errors.New("SM2 PublicKey format error")
+	}
+	return pub, nil
+}
+
+// SM2Encrypt SM2公钥加密，密文为GB/T 32918.4规定的C1C3C2格式
+func SM2Encrypt(publicKey string, plaintext []byte) ([]byte, error) {
+	pub, err := ParseSM2PublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return sm2.Encrypt(pub, plaintext, rand.Reader, sm2.C1C3C2)
+}
+
+// SM2Decrypt SM2私钥解密C1C3C2格式的密文
+func SM2Decrypt(privateKey string, ciphertext []byte) ([]byte, error) {
+	priv, err := ParseSM2PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < sm2MinCiphertextLen {
+		return nil, errors.New("SM2 ciphertext too short")
+	}
+	return sm2.Decrypt(priv, ciphertext, sm2.C1C3C2)
+}
+
+// SM2DecryptBase64 Base64解码后再进行SM2解密
+func SM2DecryptBase64(privateKey string, cryptoText string) ([]byte, error) {
+	encryptedData, err := base64.StdEncoding.DecodeString(cryptoText)
+	if err != nil {
+		return nil, err
+	}
+	return SM2Decrypt(privateKey, encryptedData)
+}
+
+// SM2EncryptASN1 SM2公钥加密，密文为GB/T 35276规定的ASN.1格式，与openssl等实现互通
+func SM2EncryptASN1(publicKey string, plaintext []byte) ([]byte, error) {
+	pub, err := ParseSM2PublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return sm2.EncryptAsn1(pub, plaintext, rand.Reader)
+}
+
+// SM2DecryptASN1 SM2私钥解密ASN.1格式的密文
+func SM2DecryptASN1(privateKey string, ciphertext []byte) ([]byte, error) {
+	priv, err := ParseSM2PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	c1c3c2, err := sm2.CipherUnmarshal(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(c1c3c2) < sm2MinCiphertextLen {
+		return nil, errors.New("SM2 ciphertext too short")
+	}
+	return sm2.Decrypt(priv, c1c3c2, sm2.C1C3C2)
+}
+
+// SM2Sign SM3withSM2签名，使用默认的用户标识1234567812345678，签名为ASN.1 DER格式
+func SM2Sign(privateKey string, msg []byte) ([]byte, error) {
+	priv, err := ParseSM2PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return priv.Sign(rand.Reader, msg, nil)
+}
+
+// SM2Verify 校验SM3withSM2签名
+func SM2Verify(publicKey string, msg []byte, signature []byte) error {
+	pub, err := ParseSM2PublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	if !pub.Verify(msg, signature) {
+		return errors.New("SM2 signature verification failed")
+	}
+	return nil
+}
+
+// SM3 计算SM3摘要，返回十六进制字符串
+func SM3(data []byte) string {
+	return hex.EncodeToString(sm3.Sm3Sum(data))
+}
+
+// SM4EncryptCBC SM4 CBC模式加密，PKCS#7填充
+func SM4EncryptCBC(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("SM4 iv length must be 16")
+	}
+	data := pkcs7Pad(plaintext, block.BlockSize())
+	ciphertext := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, data)
+	return ciphertext, nil
+}
+
+// SM4DecryptCBC SM4 CBC模式解密，去除PKCS#7填充
+func SM4DecryptCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, errors.New("SM4 iv length must be 16")
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("SM4 ciphertext is not a multiple of the block size")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	return pkcs7Unpad(plaintext, block.BlockSize())
+}
+
+// SM4EncryptECB SM4 ECB模式加密，PKCS#7填充
+func SM4EncryptECB(key, plaintext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pkcs7Pad(plaintext, block.BlockSize())
+	ciphertext := make([]byte, len(data))
+	for i := 0; i < len(data); i += block.BlockSize() {
+		block.Encrypt(ciphertext[i:], data[i:])
+	}
+	return ciphertext, nil
+}
+
+// SM4DecryptECB SM4 ECB模式解密，去除PKCS#7填充
+func SM4DecryptECB(key, ciphertext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, errors.New("SM4 ciphertext is not a multiple of the block size")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	for i := 0; i < len(ciphertext); i += block.BlockSize() {
+		block.Decrypt(plaintext[i:], ciphertext[i:])
+	}
+	return pkcs7Unpad(plaintext, block.BlockSize())
+}
+
+// pkcs7Pad PKCS#7填充
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// pkcs7Unpad 去除PKCS#7填充
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("invalid PKCS#7 padding")
+	}
+	padding := int(data[len(data)-1])
+	if padding == 0 || padding > blockSize || padding > len(data) {
+		return nil, errors.New("invalid PKCS#7 padding")
+	}
+	for _, b := range data[len(data)-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("invalid PKCS#7 padding")
+		}
+	}
+	return data[:len(data)-padding], nil
+}
@@ -1,11 +1,116 @@
 // Package config 亿企赢config配置
 package config
 
-import "github.com/yangzhenrui/finance/cache"
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yangzhenrui/finance/cache"
+	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/logger"
+	"github.com/yangzhenrui/finance/metrics"
+	"github.com/yangzhenrui/finance/ratelimit"
+	"github.com/yangzhenrui/finance/util"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// DefaultBaseURL 亿企赢开放平台网关地址
+	DefaultBaseURL = "https://openapi.17win.com/gateway"
+
+	// DefaultTimeout 默认的单次请求超时时间
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultMaxIdleConnsPerHost 默认每个host保持的空闲连接数
+	DefaultMaxIdleConnsPerHost = 20
+)
 
 // Config .config for 亿企赢
 type Config struct {
-	AppKey    string `json:"appKey"`    // appid
-	AppSecret string `json:"appSecret"` // appsecret
-	Cache     cache.Cache
+	AppKey          string `json:"appKey"`        // appid
+	AppSecret       string `json:"appSecret"`     // appsecret
+	PendingSecret   string `json:"pendingSecret"` // 待生效的appsecret，轮换期间AppSecret被拒绝时使用
+	Cache           cache.Cache
+	SignAlgorithm   credential.Algorithm          `json:"signAlgorithm"` // 签名算法，为空时使用HMAC-SHA256
+	Credentials     credential.Provider           `json:"-"`             // 多租户凭证，请求ctx带有租户ID时按租户获取，否则使用AppKey、AppSecret
+	BaseURL         string                        `json:"baseURL"`       // 网关地址，为空时使用DefaultBaseURL，可指向预发环境、代理或本地测试服务
+	HTTPClient      *http.Client                  `json:"-"`             // 自定义http客户端，设置后忽略Transport与Timeout
+	Transport       http.RoundTripper             `json:"-"`             // 自定义transport，用于代理、mTLS或测试替身
+	Timeout         time.Duration                 `json:"timeout"`       // 单次请求超时时间，为0时使用DefaultTimeout
+	Retry           *RetryPolicy                  `json:"-"`             // 重试策略，为空时使用DefaultRetryPolicy
+	ErrorClassifier *util.ErrorClassifier         `json:"-"`             // 网关错误分类，为空时使用util.DefaultErrorClassifier
+	RateLimit       ratelimit.Limiter             `json:"-"`             // 客户端限流，按AppKey共享额度，为空时不限流
+	RateLimits      map[string]ratelimit.Limit    `json:"rateLimits"`    // 按AppKey单独设置的额度，见NewRateLimiter
+	Logger          logger.Logger                 `json:"-"`             // 请求日志，为空时不输出
+	Metrics         metrics.Collector             `json:"-"`             // 指标收集，为空时不统计
+	TracerProvider  trace.TracerProvider          `json:"-"`             // 链路追踪，为空时不产生span
+	Propagator      propagation.TextMapPropagator `json:"-"`             // 向网关传递trace context，为空时使用otel全局设置
+}
+
+// NewRateLimiter 将RateLimits设置到RateLimit后返回，不修改cfg
+// RateLimit为空时使用进程内令牌桶，未单独设置的AppKey不限流；RateLimit未实现ratelimit.LimitSetter时忽略RateLimits
+func (cfg *Config) NewRateLimiter() ratelimit.Limiter {
+	if len(cfg.RateLimits) == 0 {
+		return cfg.RateLimit
+	}
+	limiter := cfg.RateLimit
+	if limiter == nil {
+		limiter = ratelimit.NewTokenBucket(ratelimit.Limit{})
+	}
+	if setter, ok := limiter.(ratelimit.LimitSetter); ok {
+		for appKey, limit := range cfg.RateLimits {
+			setter.SetLimit(appKey, limit)
+		}
+	}
+	return limiter
+}
+
+// GetBaseURL 返回接口路径解析所基于的网关地址
+func (cfg *Config) GetBaseURL() string {
+	if cfg.BaseURL == "" {
+		return DefaultBaseURL
+	}
+	return strings.TrimRight(cfg.BaseURL, "/")
+}
+
+// ErrNoCredentialProvider ctx带有租户ID但未配置Credentials
+var ErrNoCredentialProvider = errors.New("config: tenant set but no credential provider configured")
+
+// GetCredential 获取本次请求使用的凭证
+// ctx通过credential.WithTenant带有租户ID时从Credentials获取，否则使用AppKey、AppSecret
+func (cfg *Config) GetCredential(ctx context.Context) (credential.Credential, error) {
+	tenantID, ok := credential.TenantFromContext(ctx)
+	if !ok {
+		return credential.Credential{AppKey: cfg.AppKey, AppSecret: cfg.AppSecret, PendingSecret: cfg.PendingSecret}, nil
+	}
+	if cfg.Credentials == nil {
+		return credential.Credential{}, ErrNoCredentialProvider
+	}
+	return cfg.Credentials.GetCredential(ctx, tenantID)
+}
+
+// NewHTTPClient 根据配置创建请求网关使用的http客户端
+func (cfg *Config) NewHTTPClient() *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+
+	transport := cfg.Transport
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		defaultTransport.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+		transport = defaultTransport
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
 }
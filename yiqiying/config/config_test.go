@@ -0,0 +1,60 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/ratelimit"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	cfg := &Config{}
+	client := cfg.NewHTTPClient()
+	assert.Equal(t, DefaultTimeout, client.Timeout)
+	transport, ok := client.Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+
+	cfg = &Config{Transport: http.DefaultTransport, Timeout: time.Second}
+	client = cfg.NewHTTPClient()
+	assert.Equal(t, time.Second, client.Timeout)
+	assert.Equal(t, http.DefaultTransport, client.Transport)
+
+	custom := &http.Client{}
+	cfg = &Config{HTTPClient: custom, Timeout: time.Second}
+	assert.Same(t, custom, cfg.NewHTTPClient())
+}
+
+func TestGetBaseURL(t *testing.T) {
+	assert.Equal(t, DefaultBaseURL, (&Config{}).GetBaseURL())
+	assert.Equal(t, "http://127.0.0.1:8080/gateway", (&Config{BaseURL: "http://127.0.0.1:8080/gateway/"}).GetBaseURL())
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	assert.Nil(t, (&Config{}).NewRateLimiter())
+
+	var cfg Config
+	assert.Nil(t, json.Unmarshal([]byte(`{"rateLimits":{"k1":{"qps":20,"burst":1}}}`), &cfg))
+	limiter := cfg.NewRateLimiter()
+	assert.NotNil(t, limiter)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, limiter.Wait(context.Background(), "k1"))
+	}
+	assert.True(t, time.Since(start) >= 80*time.Millisecond)
+
+	// 未单独设置的appKey使用RateLimit的默认额度
+	start = time.Now()
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, limiter.Wait(context.Background(), "k2"))
+	}
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	bucket := ratelimit.NewTokenBucket(ratelimit.Limit{})
+	cfg.RateLimit = bucket
+	assert.Same(t, bucket, cfg.NewRateLimiter())
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/yangzhenrui/finance/util"
+)
+
+// RetryClassifier 判断一次失败的请求是否可以重试
+// statusCode为0表示未收到响应，head为nil表示响应无法解析
+type RetryClassifier func(statusCode int, head *util.CommonError, err error) bool
+
+// RetryPolicy 网关请求失败时的重试策略
+type RetryPolicy struct {
+	MaxAttempts        int             // 最大尝试次数(含首次请求)，小于等于1时不重试
+	InitialBackoff     time.Duration   // 第一次重试前的等待时间
+	MaxBackoff         time.Duration   // 单次等待时间上限
+	Multiplier         float64         // 退避倍数
+	Jitter             float64         // 抖动比例，取值[0,1]，实际等待时间在[d*(1-Jitter), d]之间
+	RetryableCodes     []string        // 可重试的网关错误代码，如限流
+	RetryNonIdempotent bool            // 是否对非幂等接口(新增、更新等)也进行重试
+	Classifier         RetryClassifier // 自定义重试判断，为空时使用Retryable
+}
+
+// DefaultRetryPolicy 默认的重试策略，仅对幂等的查询接口生效
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// GetRetryPolicy 返回生效的重试策略
+func (cfg *Config) GetRetryPolicy() *RetryPolicy {
+	if cfg.Retry == nil {
+		return DefaultRetryPolicy()
+	}
+	return cfg.Retry
+}
+
+// ShouldRetry 判断第attempt次请求失败后是否还需要重试
+func (p *RetryPolicy) ShouldRetry(attempt int, idempotent bool, statusCode int, head *util.CommonError, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if !idempotent && !p.RetryNonIdempotent {
+		return false
+	}
+	if p.Classifier != nil {
+		return p.Classifier(statusCode, head, err)
+	}
+	return p.Retryable(statusCode, head, err)
+}
+
+// Retryable 默认的重试判断：网络错误、http 5xx、限流以及RetryableCodes中的网关错误代码
+// 构造请求、签名或拦截器返回的错误在发送前产生，重试也不会成功，直接失败
+func (p *RetryPolicy) Retryable(statusCode int, head *util.CommonError, err error) bool {
+	if statusCode == 0 {
+		return isTransportError(err)
+	}
+	if statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError {
+		return true
+	}
+	if head != nil {
+		for _, code := range p.RetryableCodes {
+			if head.Code == code {
+				return true
+			}
+		}
+	}
+	return util.IsRateLimited(err)
+}
+
+// isTransportError 是否为发送请求时的网络错误
+func isTransportError(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Backoff 第attempt次请求失败后需要等待的时间
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/util"
+)
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy()
+	p.RetryableCodes = []string{"99999999"}
+	networkErr := &url.Error{Op: "Post", URL: "https://example.com", Err: errors.New("connection reset")}
+
+	assert.True(t, p.ShouldRetry(1, true, 0, nil, networkErr))
+	assert.True(t, p.ShouldRetry(2, true, http.StatusBadGateway, nil, networkErr))
+	assert.True(t, p.ShouldRetry(1, true, http.StatusTooManyRequests, nil, networkErr))
+	assert.True(t, p.ShouldRetry(1, true, http.StatusOK, &util.CommonError{Code: "99999999"}, networkErr))
+	assert.False(t, p.ShouldRetry(3, true, 0, nil, networkErr))
+	assert.False(t, p.ShouldRetry(1, false, 0, nil, networkErr))
+	assert.False(t, p.ShouldRetry(1, true, http.StatusOK, &util.CommonError{Code: "10000001"}, networkErr))
+	assert.False(t, p.ShouldRetry(1, true, http.StatusBadRequest, nil, networkErr))
+	// 发送前产生的错误不重试
+	assert.False(t, p.ShouldRetry(1, true, 0, nil, errors.New("unsupported sign algorithm")))
+	assert.False(t, p.ShouldRetry(1, true, 0, nil, nil))
+
+	p.RetryNonIdempotent = true
+	assert.True(t, p.ShouldRetry(1, false, 0, nil, networkErr))
+
+	p.Classifier = func(statusCode int, head *util.CommonError, err error) bool {
+		return false
+	}
+	assert.False(t, p.ShouldRetry(1, true, 0, nil, networkErr))
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 400*time.Millisecond, p.Backoff(3))
+	assert.Equal(t, time.Second, p.Backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := p.Backoff(2)
+		assert.True(t, backoff >= 100*time.Millisecond && backoff <= 200*time.Millisecond)
+	}
+}
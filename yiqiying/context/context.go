@@ -1,21 +1,69 @@
 package context
 
 import (
+	uuid "github.com/satori/go.uuid"
 	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/ratelimit"
 	"github.com/yangzhenrui/finance/yiqiying/config"
+	"net/http"
+	"strings"
+	"time"
 )
 
+// Clock 返回当前时间，用于生成请求的timestamp
+type Clock func() time.Time
+
+// NonceSource 生成请求的xReqNonce
+type NonceSource func() string
+
 // Context struct
 type Context struct {
 	*config.Config
-	credential.SignatureHandle
-	Version        string  `json:"version"`
-	Timestamp      int64   `json:"timestamp"`
-	ContentType    string  `json:"contentType"`
-	XReqNonce      string  `json:"XReqNonce"`
-	CustomerId     *string `json:"customerId"`
-	AccountPeriod  *string `json:"accountPeriod"`
-	ReclassifyFlag *string `json:"reclassifyFlag"`
-	Period         *string `json:"period"`
-	TaxCode        *string `json:"taxCode"`
+	HTTPClient     *http.Client        `json:"-"`
+	Interceptors   []Interceptor       `json:"-"`
+	Rotator        *credential.Rotator `json:"-"`
+	Limiter        ratelimit.Limiter   `json:"-"` // 为空时使用Config.RateLimit
+	Skew           *ClockSkew          `json:"-"` // 为空时不校正时钟偏差
+	Clock          Clock               `json:"-"`
+	NonceSource    NonceSource         `json:"-"`
+	Version        string              `json:"version"`
+	ContentType    string              `json:"contentType"`
+	CustomerId     *string             `json:"customerId"`
+	AccountPeriod  *string             `json:"accountPeriod"`
+	ReclassifyFlag *string             `json:"reclassifyFlag"`
+	Period         *string             `json:"period"`
+	TaxCode        *string             `json:"taxCode"`
+}
+
+// now 本地当前时间
+func (c *Context) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock()
+	}
+	return time.Now()
+}
+
+// rateLimiter 本次请求使用的限流
+func (c *Context) rateLimiter() ratelimit.Limiter {
+	if c.Limiter != nil {
+		return c.Limiter
+	}
+	return c.RateLimit
+}
+
+// timestamp 当前毫秒时间戳，按测得的时钟偏差校正为网关时间
+func (c *Context) timestamp() int64 {
+	now := c.now()
+	if c.Skew != nil {
+		now = now.Add(c.Skew.Offset())
+	}
+	return now.UnixNano() / 1e6
+}
+
+// nonce 生成新的xReqNonce
+func (c *Context) nonce() string {
+	if c.NonceSource != nil {
+		return c.NonceSource()
+	}
+	return strings.Replace(uuid.NewV4().String(), "-", "", -1)
 }
@@ -0,0 +1,59 @@
+package context
+
+import (
+	"net/http"
+
+	"github.com/yangzhenrui/finance/util"
+)
+
+// Interceptor 请求拦截器，可用于审计日志、注入请求头、统计指标及故障注入
+type Interceptor interface {
+	// BeforeSend 请求签名后、发送前调用，返回错误时放弃本次发送
+	BeforeSend(req *http.Request) error
+
+	// AfterReceive 收到响应后调用，未收到响应时不会调用
+	// head为nil表示响应无法解析，返回错误时作为本次请求的错误
+	AfterReceive(req *http.Request, statusCode int, body []byte, head *util.CommonError) error
+}
+
+// InterceptorFuncs 以函数实现Interceptor，未设置的函数不做处理
+type InterceptorFuncs struct {
+	Before func(req *http.Request) error
+	After  func(req *http.Request, statusCode int, body []byte, head *util.CommonError) error
+}
+
+// BeforeSend 请求发送前调用
+func (f InterceptorFuncs) BeforeSend(req *http.Request) error {
+	if f.Before == nil {
+		return nil
+	}
+	return f.Before(req)
+}
+
+// AfterReceive 收到响应后调用
+func (f InterceptorFuncs) AfterReceive(req *http.Request, statusCode int, body []byte, head *util.CommonError) error {
+	if f.After == nil {
+		return nil
+	}
+	return f.After(req, statusCode, body, head)
+}
+
+// beforeSend 依次调用拦截器
+func (c *Context) beforeSend(req *http.Request) error {
+	for _, interceptor := range c.Interceptors {
+		if err := interceptor.BeforeSend(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// afterReceive 依次调用拦截器
+func (c *Context) afterReceive(req *http.Request, statusCode int, body []byte, head *util.CommonError) error {
+	for _, interceptor := range c.Interceptors {
+		if err := interceptor.AfterReceive(req, statusCode, body, head); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package context
+
+import (
+	"time"
+
+	"github.com/yangzhenrui/finance/logger"
+	"github.com/yangzhenrui/finance/util"
+)
+
+// attempt 单次请求的结果，用于重试判断及日志
+type attempt struct {
+	number     int
+	appKey     string
+	statusCode int
+	head       *util.CommonError
+	xReqNonce  string
+	latency    time.Duration
+}
+
+// getLogger 未设置Logger时不输出日志
+func (c *Context) getLogger() logger.Logger {
+	if c.Logger == nil {
+		return logger.Nop{}
+	}
+	return c.Logger
+}
+
+// logAttempt 记录单次请求，签名与appSecret不会出现在日志中
+func (c *Context) logAttempt(req *Request, a *attempt, err error) {
+	fields := logger.Fields{
+		"service":    req.Service,
+		"operation":  req.Operation,
+		"httpMethod": req.httpMethod(),
+		"endpoint":   req.URL,
+		"customerId": req.param("customerId"),
+		"period":     req.period(),
+		"appKey":     a.appKey,
+		"attempt":    a.number,
+		"statusCode": a.statusCode,
+		"xReqNonce":  a.xReqNonce,
+		"latencyMs":  a.latency.Milliseconds(),
+	}
+	if a.head != nil {
+		fields["code"] = a.head.Code
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		c.getLogger().Error("yiqiying request failed", fields)
+		return
+	}
+	c.getLogger().Info("yiqiying request", fields)
+}
@@ -0,0 +1,53 @@
+package context
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/yangzhenrui/finance/metrics"
+)
+
+// errorCode 指标中的错误代码：网关错误代码，无法解析时为http状态码，未收到响应时为network
+func (a *attempt) errorCode() string {
+	switch {
+	case a.head != nil:
+		return a.head.Code
+	case a.statusCode != 0:
+		return "http_" + strconv.Itoa(a.statusCode)
+	default:
+		return "network"
+	}
+}
+
+// observeAttempt 记录单次请求的指标
+func (c *Context) observeAttempt(req *Request, a *attempt, err error) {
+	if c.Metrics == nil {
+		return
+	}
+	code := ""
+	if err != nil {
+		code = a.errorCode()
+	}
+	c.Metrics.ObserveRequest(req.Service, req.Operation, a.latency, code, err)
+}
+
+// observeRetry 记录一次重试
+func (c *Context) observeRetry(req *Request) {
+	if c.Metrics != nil {
+		c.Metrics.ObserveRetry(req.Service, req.Operation)
+	}
+}
+
+// observeRateLimitWait 记录限流等待时间
+func (c *Context) observeRateLimitWait(req *Request, wait time.Duration) {
+	if c.Metrics != nil {
+		c.Metrics.ObserveRateLimitWait(req.Service, req.Operation, wait)
+	}
+}
+
+// observeClockSkew Collector实现了metrics.ClockSkewObserver时记录时钟偏差
+func (c *Context) observeClockSkew(skew time.Duration) {
+	if observer, ok := c.Metrics.(metrics.ClockSkewObserver); ok {
+		observer.ObserveClockSkew(skew)
+	}
+}
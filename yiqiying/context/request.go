@@ -0,0 +1,303 @@
+package context
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/logger"
+	"github.com/yangzhenrui/finance/util"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// ContentTypeJSON json请求体
+	ContentTypeJSON = "application/json"
+
+	// ContentTypeForm 表单请求体
+	ContentTypeForm = "application/x-www-form-urlencoded"
+
+	// successCode 网关返回成功的代码
+	successCode = "00000000"
+)
+
+// SignParams 参与签名的业务参数，参数名为网关约定的名称，如customerId、period
+type SignParams map[string]string
+
+// Request 一次网关调用的描述
+type Request struct {
+	Method      string            // 请求方法，默认GET
+	URL         string            // 接口路径，基于Config.BaseURL解析；完整地址则直接使用
+	Query       url.Values        // GET查询参数
+	JSON        interface{}       // 以json方式提交的请求体
+	Form        url.Values        // 以表单方式提交的请求体
+	ContentType string            // 覆盖默认的Content-Type
+	Header      map[string]string // 额外的请求头
+	Service     string            // 服务名，如Tax，用于日志、统计
+	Operation   string            // 服务方法名，如GetTaxList，用于日志、统计
+	Idempotent  bool              // 是否为可安全重试的查询接口，GET请求总是视为幂等
+	Sign        SignParams        // 参与签名的业务参数
+	ErrMsg      string            // 网关返回失败时的错误描述
+}
+
+// httpMethod 请求方法，默认GET
+func (req *Request) httpMethod() string {
+	if req.Method == "" {
+		return http.MethodGet
+	}
+	return req.Method
+}
+
+// idempotent 请求是否可以安全重试
+func (req *Request) idempotent() bool {
+	return req.Idempotent || req.httpMethod() == http.MethodGet
+}
+
+// param 获取业务参数，依次从签名参数、查询参数中查找
+func (req *Request) param(name string) string {
+	if value, ok := req.Sign[name]; ok {
+		return value
+	}
+	return req.Query.Get(name)
+}
+
+// period 会计期间，财务类接口为accountPeriod
+func (req *Request) period() string {
+	if period := req.param("period"); period != "" {
+		return period
+	}
+	return req.param("accountPeriod")
+}
+
+// gatewayResponse 网关返回的公共部分
+type gatewayResponse struct {
+	Head util.CommonError `json:"head"`
+}
+
+// Execute 签名并发送请求，将返回结果解析到result中
+func (c *Context) Execute(req *Request, result interface{}) error {
+	return c.ExecuteCtx(gocontext.Background(), req, result)
+}
+
+// ExecuteCtx 同Execute，请求随ctx取消或超时，此时返回ctx.Err()
+// 失败时按Config.Retry重试，每次重试都会重新签名
+// ctx通过credential.WithTenant带有租户ID时，使用Config.Credentials中该租户的凭证
+func (c *Context) ExecuteCtx(ctx gocontext.Context, req *Request, result interface{}) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	ctx, span := c.startSpan(ctx, req)
+	var last *attempt
+	defer func() {
+		c.endSpan(span, last, err)
+	}()
+
+	cred, err := c.GetCredential(ctx)
+	if err != nil {
+		return
+	}
+	if c.Rotator != nil {
+		cred = c.Rotator.Active(cred)
+	}
+	triedPending := false
+
+	policy := c.GetRetryPolicy()
+	for number := 1; ; number++ {
+		// 每次尝试都消耗一个额度，重试也不例外
+		if limiter := c.rateLimiter(); limiter != nil {
+			waitStart := time.Now()
+			err = limiter.Wait(ctx, cred.AppKey)
+			c.observeRateLimitWait(req, time.Since(waitStart))
+			if err != nil {
+				return
+			}
+		}
+
+		a := &attempt{number: number, appKey: cred.AppKey}
+		last = a
+		start := time.Now()
+		err = c.do(ctx, req, cred, result, a)
+		a.latency = time.Since(start)
+		c.logAttempt(req, a, err)
+		c.observeAttempt(req, a, err)
+		if triedPending {
+			c.finishRotation(cred, err)
+		}
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		// 密钥被拒绝时立即改用待生效密钥重试一次，无需退避
+		if !triedPending && cred.PendingSecret != "" && util.IsSignatureError(err) {
+			c.emitRotation(credential.RotationEvent{Type: credential.RotationPendingTried, AppKey: cred.AppKey, Err: err})
+			cred = credential.Credential{AppKey: cred.AppKey, AppSecret: cred.PendingSecret, PendingSecret: cred.PendingSecret}
+			triedPending = true
+			continue
+		}
+		if !policy.ShouldRetry(number, req.idempotent(), a.statusCode, a.head, err) {
+			return
+		}
+		c.observeRetry(req)
+
+		timer := time.NewTimer(policy.Backoff(number))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// finishRotation 待生效密钥通过校验则提升，被拒绝则通知
+func (c *Context) finishRotation(cred credential.Credential, err error) {
+	switch {
+	case err == nil:
+		if c.Rotator != nil {
+			c.Rotator.Promote(cred)
+		}
+	case util.IsSignatureError(err):
+		c.emitRotation(credential.RotationEvent{Type: credential.RotationPendingRejected, AppKey: cred.AppKey, Err: err})
+	}
+}
+
+// emitRotation 未设置Rotator时不通知
+func (c *Context) emitRotation(event credential.RotationEvent) {
+	if c.Rotator != nil {
+		c.Rotator.Emit(event)
+	}
+}
+
+// do 发送一次请求，并将http状态码、网关返回的head等记录到a中
+func (c *Context) do(ctx gocontext.Context, req *Request, cred credential.Credential, result interface{}, a *attempt) (err error) {
+	httpRequest, err := c.newHTTPRequest(ctx, req)
+	if err != nil {
+		return
+	}
+
+	// timestamp与xReqNonce每次请求重新生成，避免被网关的防重放校验拒绝
+	timestamp, xReqNonce := c.timestamp(), c.nonce()
+	a.xReqNonce = xReqNonce
+	// 签名状态只属于本次请求，不能写回共享的Context，否则并发调用会互相覆盖
+	signatureHandle := credential.NewSignatureWithAlgorithm(c.SignAlgorithm, cred.AppKey, cred.AppSecret, timestamp, c.Version, xReqNonce, req.Sign)
+	signature, err := signatureHandle.GetSignature()
+	if err != nil {
+		return
+	}
+	c.setHeader(req, cred.AppKey, signature, timestamp, xReqNonce, httpRequest)
+	c.getPropagator().Inject(ctx, propagation.HeaderCarrier(httpRequest.Header))
+	if err = c.beforeSend(httpRequest); err != nil {
+		return
+	}
+	c.getLogger().Debug("yiqiying request", logger.Fields{
+		"url":    httpRequest.URL.String(),
+		"header": logger.RedactHeader(httpRequest.Header),
+	})
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	sent := c.now()
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return
+	}
+	defer response.Body.Close()
+	a.statusCode = response.StatusCode
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return
+	}
+
+	var gatewayResp gatewayResponse
+	decodeErr := json.Unmarshal(body, &gatewayResp)
+	if decodeErr == nil {
+		a.head = &gatewayResp.Head
+	}
+	apiErr := util.NewAPIError(req.ErrMsg, a.statusCode, a.head, c.ErrorClassifier)
+	apiErr.URL = httpRequest.URL.String()
+	apiErr.XReqNonce = xReqNonce
+	c.observeSkew(sent, c.now(), response.Header.Get("Date"), a.head)
+	if err = c.afterReceive(httpRequest, a.statusCode, body, a.head); err != nil {
+		return
+	}
+	if decodeErr != nil {
+		apiErr.Err = decodeErr
+		err = apiErr
+		return
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		apiErr.Err = err
+		err = apiErr
+		return
+	}
+	if a.head.Status != "Y" || a.head.Code != successCode {
+		err = apiErr
+		return
+	}
+	return
+}
+
+// newHTTPRequest 根据Request构造http请求
+func (c *Context) newHTTPRequest(ctx gocontext.Context, req *Request) (*http.Request, error) {
+	method := req.httpMethod()
+
+	uri := req.URL
+	if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+		uri = c.GetBaseURL() + uri
+	}
+	if len(req.Query) > 0 {
+		uri = fmt.Sprintf("%v?%v", uri, req.Query.Encode())
+	}
+
+	var body io.Reader
+	switch {
+	case req.JSON != nil:
+		data, err := json.Marshal(req.JSON)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	case req.Form != nil:
+		body = strings.NewReader(req.Form.Encode())
+	}
+
+	return http.NewRequestWithContext(ctx, method, uri, body)
+}
+
+// setHeader 设置公共请求头
+func (c *Context) setHeader(req *Request, appKey, signature string, timestamp int64, xReqNonce string, httpRequest *http.Request) {
+	contentType := req.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+		if req.Form != nil {
+			contentType = ContentTypeForm
+		}
+	}
+	httpRequest.Header.Set("Content-Type", contentType)
+	httpRequest.Header.Set("version", c.Version)
+	httpRequest.Header.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	httpRequest.Header.Set("appKey", appKey)
+	httpRequest.Header.Set("signature", signature)
+	httpRequest.Header.Set("xReqNonce", xReqNonce)
+	for k, v := range req.Header {
+		httpRequest.Header.Set(k, v)
+	}
+}
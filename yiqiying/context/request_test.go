@@ -0,0 +1,547 @@
+package context
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/logger"
+	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testResponse struct {
+	Head util.CommonError `json:"head"`
+	Body string           `json:"body"`
+}
+
+func newTestContext() *Context {
+	return &Context{
+		Config: &config.Config{
+			AppKey:    "appKey",
+			AppSecret: "appSecret",
+			Cache:     cache.NewMemory(),
+			Retry: &config.RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				Multiplier:     2,
+			},
+		},
+		Clock: func() time.Time {
+			return time.Unix(1650000000, 0)
+		},
+		NonceSource: func() string {
+			return "nonce"
+		},
+		Version: "1.0.0",
+	}
+}
+
+func TestExecuteGet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "c1", r.URL.Query().Get("customerId"))
+		assert.Equal(t, "c1", r.Header.Get("customerId"))
+		assert.Equal(t, ContentTypeJSON, r.Header.Get("Content-Type"))
+		assert.Equal(t, "appKey", r.Header.Get("appKey"))
+		assert.Equal(t, "1650000000000", r.Header.Get("timestamp"))
+		assert.Equal(t, "nonce", r.Header.Get("xReqNonce"))
+		assert.NotEmpty(t, r.Header.Get("signature"))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":"ok"}`))
+	}))
+	defer server.Close()
+
+	customerID := "c1"
+	var result testResponse
+	err := newTestContext().Execute(&Request{
+		URL:    server.URL,
+		Query:  url.Values{"customerId": {customerID}},
+		Header: map[string]string{"customerId": customerID},
+		Sign:   SignParams{"customerId": customerID},
+	}, &result)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", result.Body)
+}
+
+func TestExecutePost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.Header.Get("Content-Type") {
+		case ContentTypeJSON:
+			var req map[string]string
+			assert.Nil(t, json.Unmarshal(body, &req))
+			assert.Equal(t, "bar", req["foo"])
+		case ContentTypeForm:
+			values, err := url.ParseQuery(string(body))
+			assert.Nil(t, err)
+			assert.Equal(t, "bar", values.Get("foo"))
+		default:
+			t.Errorf("unexpected content type %s", r.Header.Get("Content-Type"))
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":"ok"}`))
+	}))
+	defer server.Close()
+
+	var result testResponse
+	err := newTestContext().Execute(&Request{
+		Method: http.MethodPost,
+		URL:    server.URL,
+		JSON:   map[string]string{"foo": "bar"},
+	}, &result)
+	assert.Nil(t, err)
+
+	err = newTestContext().Execute(&Request{
+		Method: http.MethodPost,
+		URL:    server.URL,
+		Form:   url.Values{"foo": {"bar"}},
+	}, &result)
+	assert.Nil(t, err)
+}
+
+func TestExecuteGatewayError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"head":{"code":"10000001","msg":"签名错误","description":"signature invalid","status":"N"}}`))
+	}))
+	defer server.Close()
+
+	var result testResponse
+	err := newTestContext().Execute(&Request{URL: server.URL, ErrMsg: "测试接口出错"}, &result)
+	assert.EqualError(t, err, "测试接口出错,签名错误,signature invalid 出错代码为(10000001)")
+	assert.Equal(t, "10000001", result.Head.Code)
+
+	apiErr, ok := util.AsAPIError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "10000001", apiErr.Code)
+	assert.Equal(t, http.StatusOK, apiErr.StatusCode)
+	assert.Equal(t, "nonce", apiErr.XReqNonce)
+	assert.Equal(t, server.URL, apiErr.URL)
+	assert.True(t, util.IsSignatureError(err))
+	assert.False(t, util.IsRateLimited(err))
+}
+
+func TestExecuteErrorClassifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"head":{"code":"20000002","msg":"客户未开通","status":"N"}}`))
+	}))
+	defer server.Close()
+
+	c := newTestContext()
+	var result testResponse
+	err := c.Execute(&Request{Method: http.MethodGet, URL: server.URL, ErrMsg: "测试接口出错"}, &result)
+	assert.False(t, util.IsNotFound(err))
+
+	classifier := util.DefaultErrorClassifier()
+	classifier.NotFoundCodes = []string{"20000002"}
+	c.ErrorClassifier = classifier
+	err = c.Execute(&Request{Method: http.MethodGet, URL: server.URL, ErrMsg: "测试接口出错"}, &result)
+	assert.True(t, util.IsNotFound(err))
+}
+
+func TestExecuteTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var result testResponse
+	err := newTestContext().Execute(&Request{URL: server.URL}, &result)
+	assert.NotNil(t, err)
+}
+
+func TestExecuteCtxCanceled(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 50*time.Millisecond)
+	defer cancel()
+	var result testResponse
+	err := newTestContext().ExecuteCtx(ctx, &Request{URL: server.URL}, &result)
+	assert.Equal(t, gocontext.DeadlineExceeded, err)
+
+	ctx, cancel = gocontext.WithCancel(gocontext.Background())
+	cancel()
+	err = newTestContext().ExecuteCtx(ctx, &Request{URL: server.URL}, &result)
+	assert.Equal(t, gocontext.Canceled, err)
+}
+
+func TestExecuteFreshNonce(t *testing.T) {
+	nonces := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces[r.Header.Get("xReqNonce")] = true
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	ctx := newTestContext()
+	ctx.NonceSource = nil
+	var result testResponse
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	}
+	assert.Len(t, nonces, 3)
+	for nonce := range nonces {
+		assert.Len(t, nonce, 32)
+	}
+}
+
+// TestExecuteConcurrent 并发调用时每个请求都应使用自己的参数签名，需配合go test -race运行
+func TestExecuteConcurrent(t *testing.T) {
+	ctx := newTestContext()
+	ctx.Clock = nil
+	ctx.NonceSource = nil
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		customerID, period := r.URL.Query().Get("customerId"), r.URL.Query().Get("period")
+		timestamp, _ := strconv.ParseInt(r.Header.Get("timestamp"), 10, 64)
+		expected, _ := credential.NewDefaultSignature(nil, ctx.AppKey, ctx.AppSecret, &customerID, &period, nil, nil, timestamp, r.Header.Get("version"), r.Header.Get("xReqNonce"), credential.CacheKeyYiQiYingPrefix, ctx.Cache).GetSignature()
+		if r.Header.Get("signature") != expected {
+			_, _ = w.Write([]byte(`{"head":{"code":"10000001","status":"N"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":"` + customerID + `"}`))
+	}))
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			customerID, period := strconv.Itoa(i), "2022"+strconv.Itoa(i%12+10)
+			var result testResponse
+			err := ctx.Execute(&Request{
+				URL:   server.URL,
+				Query: url.Values{"customerId": {customerID}, "period": {period}},
+				Sign:  SignParams{"customerId": customerID, "period": period},
+			}, &result)
+			assert.Nil(t, err)
+			assert.Equal(t, customerID, result.Body)
+		}(i)
+	}
+	wg.Wait()
+}
+
+type recordingCollector struct {
+	codes   []string
+	retries int
+}
+
+func (c *recordingCollector) ObserveRequest(service, operation string, latency time.Duration, code string, err error) {
+	c.codes = append(c.codes, code)
+}
+
+func (c *recordingCollector) ObserveRetry(service, operation string) {
+	c.retries++
+}
+
+func (c *recordingCollector) ObserveRateLimitWait(service, operation string, wait time.Duration) {}
+
+func TestExecuteRetry(t *testing.T) {
+	var nonces []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, r.Header.Get("xReqNonce"))
+		if len(nonces) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":"ok"}`))
+	}))
+	defer server.Close()
+
+	collector := &recordingCollector{}
+	ctx := newTestContext()
+	ctx.NonceSource = nil
+	ctx.Metrics = collector
+	var result testResponse
+	err := ctx.Execute(&Request{URL: server.URL}, &result)
+	assert.Nil(t, err)
+	assert.Equal(t, "ok", result.Body)
+	assert.Len(t, nonces, 3)
+	assert.Equal(t, []string{"http_503", "http_503", ""}, collector.codes)
+	assert.Equal(t, 2, collector.retries)
+	assert.NotEqual(t, nonces[0], nonces[1])
+	assert.NotEqual(t, nonces[1], nonces[2])
+
+	// 非幂等接口默认不重试
+	nonces = nil
+	err = ctx.Execute(&Request{Method: http.MethodPost, URL: server.URL, JSON: map[string]string{}}, &result)
+	assert.NotNil(t, err)
+	assert.Len(t, nonces, 1)
+}
+
+func TestExecuteNoRetryBeforeSend(t *testing.T) {
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	// 不支持的签名算法
+	collector := &recordingCollector{}
+	ctx := newTestContext()
+	ctx.Metrics = collector
+	ctx.SignAlgorithm = "HMAC-MD5"
+	var result testResponse
+	assert.NotNil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Len(t, collector.codes, 1)
+	assert.Equal(t, 0, collector.retries)
+
+	// 拦截器拒绝发送
+	rejected := errors.New("rejected")
+	var calls int
+	collector = &recordingCollector{}
+	ctx = newTestContext()
+	ctx.Metrics = collector
+	ctx.Interceptors = []Interceptor{InterceptorFuncs{Before: func(req *http.Request) error {
+		calls++
+		return rejected
+	}}}
+	assert.ErrorIs(t, ctx.Execute(&Request{URL: server.URL}, &result), rejected)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, collector.retries)
+	assert.Equal(t, 0, hits)
+}
+
+type countingLimiter struct {
+	keys []string
+}
+
+func (l *countingLimiter) Wait(ctx gocontext.Context, key string) error {
+	l.keys = append(l.keys, key)
+	return nil
+}
+
+func TestExecuteRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	ctx := newTestContext()
+	ctx.RateLimit = limiter
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, []string{"appKey", "appKey"}, limiter.keys)
+}
+
+func TestExecuteTenantCredential(t *testing.T) {
+	var appKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appKeys = append(appKeys, r.Header.Get("appKey"))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	ctx := newTestContext()
+	ctx.RateLimit = limiter
+	ctx.Credentials = credential.StaticProvider{
+		"t1": {AppKey: "k1", AppSecret: "s1"},
+		"t2": {AppKey: "k2", AppSecret: "s2"},
+	}
+	var result testResponse
+	assert.Nil(t, ctx.ExecuteCtx(credential.WithTenant(gocontext.Background(), "t1"), &Request{URL: server.URL}, &result))
+	assert.Nil(t, ctx.ExecuteCtx(credential.WithTenant(gocontext.Background(), "t2"), &Request{URL: server.URL}, &result))
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, []string{"k1", "k2", "appKey"}, appKeys)
+	assert.Equal(t, []string{"k1", "k2", "appKey"}, limiter.keys)
+
+	err := ctx.ExecuteCtx(credential.WithTenant(gocontext.Background(), "t3"), &Request{URL: server.URL}, &result)
+	assert.ErrorIs(t, err, credential.ErrCredentialNotFound)
+	assert.Len(t, appKeys, 3)
+}
+
+func TestExecuteSecretRotation(t *testing.T) {
+	newSignature, err := credential.NewSignature("appKey", "newSecret", 1650000000000, "1.0.0", "nonce", nil).GetSignature()
+	assert.Nil(t, err)
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("signature") != newSignature {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"head":{"code":"signature","status":"N"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	var events []credential.RotationEventType
+	ctx := newTestContext()
+	ctx.PendingSecret = "newSecret"
+	ctx.Rotator = credential.NewRotator(func(event credential.RotationEvent) {
+		assert.Equal(t, "appKey", event.AppKey)
+		events = append(events, event.Type)
+	})
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, []credential.RotationEventType{credential.RotationPendingTried, credential.RotationPromoted}, events)
+
+	// 提升后直接使用新密钥
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, 3, hits)
+	assert.Len(t, events, 2)
+
+	// 待生效密钥同样被拒绝
+	hits, events = 0, nil
+	ctx = newTestContext()
+	ctx.PendingSecret = "wrongSecret"
+	ctx.Rotator = credential.NewRotator(func(event credential.RotationEvent) {
+		events = append(events, event.Type)
+	})
+	err = ctx.Execute(&Request{URL: server.URL}, &result)
+	assert.True(t, util.IsSignatureError(err))
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, []credential.RotationEventType{credential.RotationPendingTried, credential.RotationPendingRejected}, events)
+}
+
+func TestExecuteSecretRotationGatewayCode(t *testing.T) {
+	newSignature, err := credential.NewSignature("appKey", "newSecret", 1650000000000, "1.0.0", "nonce", nil).GetSignature()
+	assert.Nil(t, err)
+	var hits int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("signature") != newSignature {
+			// 网关以http 200返回签名错误
+			_, _ = w.Write([]byte(`{"head":{"code":"10000001","msg":"签名错误","status":"N"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	var events []credential.RotationEventType
+	ctx := newTestContext()
+	ctx.PendingSecret = "newSecret"
+	ctx.Rotator = credential.NewRotator(func(event credential.RotationEvent) {
+		events = append(events, event.Type)
+	})
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, 2, hits)
+	assert.Equal(t, []credential.RotationEventType{credential.RotationPendingTried, credential.RotationPromoted}, events)
+}
+
+type recordingLogger struct {
+	entries []logger.Fields
+}
+
+func (l *recordingLogger) Debug(msg string, fields logger.Fields) {
+	l.entries = append(l.entries, fields)
+}
+
+func (l *recordingLogger) Info(msg string, fields logger.Fields) {
+	l.entries = append(l.entries, fields)
+}
+
+func (l *recordingLogger) Error(msg string, fields logger.Fields) {
+	l.entries = append(l.entries, fields)
+}
+
+func TestExecuteLogging(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	l := &recordingLogger{}
+	ctx := newTestContext()
+	ctx.Logger = l
+	customerID, period := "c1", "202205"
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{
+		Service:   "Tax",
+		Operation: "GetTaxList",
+		URL:       server.URL,
+		Sign:      SignParams{"customerId": customerID, "period": period},
+	}, &result))
+
+	assert.Len(t, l.entries, 2)
+	header := l.entries[0]["header"].(http.Header)
+	assert.Equal(t, "******", header.Get("signature"))
+	assert.Equal(t, "appKey", header.Get("appKey"))
+
+	entry := l.entries[1]
+	assert.Equal(t, "Tax", entry["service"])
+	assert.Equal(t, "GetTaxList", entry["operation"])
+	assert.Equal(t, "c1", entry["customerId"])
+	assert.Equal(t, "202205", entry["period"])
+	assert.Equal(t, "00000000", entry["code"])
+	assert.Equal(t, "nonce", entry["xReqNonce"])
+	assert.Equal(t, http.StatusOK, entry["statusCode"])
+	for _, e := range l.entries {
+		for _, v := range e {
+			assert.NotContains(t, fmt.Sprint(v), "appSecret")
+		}
+	}
+}
+
+func TestExecuteTracing(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"head":{"code":"10000001","status":"N"}}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx := newTestContext()
+	ctx.TracerProvider = provider
+	ctx.Propagator = propagation.TraceContext{}
+
+	parentCtx, parent := provider.Tracer("test").Start(gocontext.Background(), "parent")
+	customerID := "c1"
+	var result testResponse
+	err := ctx.ExecuteCtx(parentCtx, &Request{
+		Service:   "Tax",
+		Operation: "GetTaxList",
+		URL:       server.URL,
+		Sign:      SignParams{"customerId": customerID},
+	}, &result)
+	parent.End()
+	assert.NotNil(t, err)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "yiqiying Tax.GetTaxList", span.Name())
+	assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	assert.Equal(t, "c1", attributes["yiqiying.customer_id"].AsString())
+	assert.Equal(t, "10000001", attributes["yiqiying.result_code"].AsString())
+}
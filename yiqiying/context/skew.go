@@ -0,0 +1,152 @@
+package context
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yangzhenrui/finance/util"
+)
+
+// datePrecision Date头只精确到秒，偏差小于此值时视为时钟一致
+const datePrecision = time.Second
+
+// gatewayLocation 网关返回的时间字符串所在时区
+var gatewayLocation = time.FixedZone("CST", 8*3600)
+
+const (
+	// DefaultSkewSamples 计算偏差时保留的最近样本数
+	DefaultSkewSamples = 5
+
+	// DefaultMaxSkew 偏差上限，超过时视为异常值(如代理改写了Date头)丢弃
+	DefaultMaxSkew = 10 * time.Minute
+)
+
+// ClockSkew 网关时间与本地时间的偏差，并发安全
+// 每次收到响应时根据Date头或head.time采样，取最近若干次样本的中位数，签名的timestamp会加上该偏差
+type ClockSkew struct {
+	offset   int64 // 纳秒，网关时间减本地时间
+	measured int32
+
+	mu        sync.Mutex
+	samples   []time.Duration
+	next      int
+	size      int
+	maxOffset time.Duration
+}
+
+// NewClockSkew 以DefaultSkewSamples及DefaultMaxSkew实例化
+func NewClockSkew() *ClockSkew {
+	return NewClockSkewWithLimit(DefaultSkewSamples, DefaultMaxSkew)
+}
+
+// NewClockSkewWithLimit 实例化，samples为参与中位数计算的样本数，maxOffset为可接受的最大偏差，小于等于0时不限制
+func NewClockSkewWithLimit(samples int, maxOffset time.Duration) *ClockSkew {
+	if samples < 1 {
+		samples = 1
+	}
+	return &ClockSkew{
+		samples:   make([]time.Duration, samples),
+		maxOffset: maxOffset,
+	}
+}
+
+// Offset 最近样本的中位数，网关时间快于本地时为正
+func (s *ClockSkew) Offset() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.offset))
+}
+
+// Measured 是否已测得偏差
+func (s *ClockSkew) Measured() bool {
+	return atomic.LoadInt32(&s.measured) == 1
+}
+
+// Update 加入一次样本，超过上限的样本被丢弃并返回false
+func (s *ClockSkew) Update(offset time.Duration) bool {
+	if s.maxOffset > 0 && (offset > s.maxOffset || offset < -s.maxOffset) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[s.next] = offset
+	s.next = (s.next + 1) % len(s.samples)
+	if s.size < len(s.samples) {
+		s.size++
+	}
+	sorted := make([]time.Duration, s.size)
+	copy(sorted, s.samples[:s.size])
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	median := sorted[s.size/2]
+	if s.size%2 == 0 {
+		median = (sorted[s.size/2-1] + median) / 2
+	}
+	atomic.StoreInt64(&s.offset, int64(median))
+	atomic.StoreInt32(&s.measured, 1)
+	return true
+}
+
+// observeSkew 根据一次响应估算偏差，以请求发出与收到响应的中点作为本地时间
+func (c *Context) observeSkew(sent, received time.Time, date string, head *util.CommonError) {
+	if c.Skew == nil {
+		return
+	}
+	serverTime, precision, ok := parseGatewayTime(date, head)
+	if !ok {
+		return
+	}
+	local := sent.Add(received.Sub(sent) / 2)
+	offset := serverTime.Sub(local)
+	if offset > -precision && offset < precision {
+		offset = 0
+	}
+	if c.Skew.Update(offset) {
+		c.observeClockSkew(c.Skew.Offset())
+	}
+}
+
+// parseGatewayTime 优先使用精度更高的head.time，其次为Date头
+func parseGatewayTime(date string, head *util.CommonError) (time.Time, time.Duration, bool) {
+	if head != nil && head.Time != "" {
+		if t, precision, ok := parseHeadTime(head.Time); ok {
+			return t, precision, true
+		}
+	}
+	if date != "" {
+		if t, err := time.Parse(time.RFC1123, date); err == nil {
+			return t, datePrecision, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// parseHeadTime 兼容毫秒、秒时间戳及常见的日期格式
+func parseHeadTime(value string) (time.Time, time.Duration, bool) {
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		switch len(value) {
+		case 13:
+			return time.Unix(0, n*int64(time.Millisecond)), time.Millisecond, true
+		case 10:
+			return time.Unix(n, 0), time.Second, true
+		}
+		return time.Time{}, 0, false
+	}
+	layouts := []struct {
+		layout    string
+		precision time.Duration
+	}{
+		{"2006-01-02 15:04:05.000", time.Millisecond},
+		{"2006-01-02 15:04:05", time.Second},
+		{time.RFC3339Nano, time.Millisecond},
+	}
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l.layout, value, gatewayLocation); err == nil {
+			return t, l.precision, true
+		}
+	}
+	return time.Time{}, 0, false
+}
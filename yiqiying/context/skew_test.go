@@ -0,0 +1,125 @@
+package context
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/util"
+)
+
+func TestParseGatewayTime(t *testing.T) {
+	want := time.Date(2022, 4, 15, 13, 20, 0, 0, time.UTC)
+	cases := []struct {
+		date      string
+		headTime  string
+		want      time.Time
+		precision time.Duration
+	}{
+		{headTime: "1650028800123", want: want.Add(123 * time.Millisecond), precision: time.Millisecond},
+		{headTime: "1650028800", want: want, precision: time.Second},
+		{headTime: "2022-04-15 21:20:00.123", want: want.Add(123 * time.Millisecond), precision: time.Millisecond},
+		{headTime: "2022-04-15 21:20:00", want: want, precision: time.Second},
+		{headTime: "2022-04-15T21:20:00+08:00", want: want, precision: time.Millisecond},
+		{date: "Fri, 15 Apr 2022 13:20:00 GMT", want: want, precision: time.Second},
+		{date: "Fri, 15 Apr 2022 13:20:00 GMT", headTime: "bad", want: want, precision: time.Second},
+	}
+	for _, c := range cases {
+		got, precision, ok := parseGatewayTime(c.date, &util.CommonError{Time: c.headTime})
+		assert.True(t, ok, c)
+		assert.True(t, c.want.Equal(got), "%v: %v", c, got)
+		assert.Equal(t, c.precision, precision)
+	}
+
+	_, _, ok := parseGatewayTime("", &util.CommonError{Time: "12345"})
+	assert.False(t, ok)
+	_, _, ok = parseGatewayTime("", nil)
+	assert.False(t, ok)
+}
+
+func TestExecuteClockSkew(t *testing.T) {
+	local := time.Unix(1650000000, 0)
+	var timestamps []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamps = append(timestamps, r.Header.Get("timestamp"))
+		w.Header().Set("Date", local.Add(2*time.Minute).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	ctx := newTestContext()
+	ctx.Skew = NewClockSkew()
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.True(t, ctx.Skew.Measured())
+	assert.Equal(t, 2*time.Minute, ctx.Skew.Offset())
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, []string{"1650000000000", "1650000120000"}, timestamps)
+}
+
+func TestExecuteClockSkewWithinPrecision(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y","time":"1650000000300"}}`))
+	}))
+	defer server.Close()
+
+	ctx := newTestContext()
+	ctx.Skew = NewClockSkew()
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, 300*time.Millisecond, ctx.Skew.Offset())
+
+	// Date头只精确到秒，秒级以内的偏差忽略
+	ctx.Skew = NewClockSkew()
+	ctx.Clock = func() time.Time {
+		return time.Unix(1650000000, 400*int64(time.Millisecond))
+	}
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Unix(1650000000, 0).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	})
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.Equal(t, time.Duration(0), ctx.Skew.Offset())
+}
+
+func TestClockSkewUpdate(t *testing.T) {
+	s := NewClockSkewWithLimit(3, time.Minute)
+	assert.False(t, s.Measured())
+
+	assert.True(t, s.Update(10*time.Second))
+	assert.Equal(t, 10*time.Second, s.Offset())
+	assert.True(t, s.Update(20*time.Second))
+	assert.Equal(t, 15*time.Second, s.Offset())
+
+	// 单次抖动不会改变中位数
+	assert.True(t, s.Update(50*time.Second))
+	assert.Equal(t, 20*time.Second, s.Offset())
+
+	// 超过上限的样本丢弃
+	assert.False(t, s.Update(time.Hour))
+	assert.False(t, s.Update(-time.Hour))
+	assert.Equal(t, 20*time.Second, s.Offset())
+
+	// 只保留最近的样本
+	assert.True(t, s.Update(-5*time.Second))
+	assert.True(t, s.Update(-5*time.Second))
+	assert.Equal(t, -5*time.Second, s.Offset())
+	assert.True(t, s.Measured())
+}
+
+func TestExecuteClockSkewOutlier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Unix(1650000000, 0).Add(time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	}))
+	defer server.Close()
+
+	ctx := newTestContext()
+	ctx.Skew = NewClockSkew()
+	var result testResponse
+	assert.Nil(t, ctx.Execute(&Request{URL: server.URL}, &result))
+	assert.False(t, ctx.Skew.Measured())
+	assert.Equal(t, time.Duration(0), ctx.Skew.Offset())
+}
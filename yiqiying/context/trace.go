@@ -0,0 +1,64 @@
+package context
+
+import (
+	gocontext "context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName 创建tracer使用的instrumentation名称
+const tracerName = "github.com/yangzhenrui/finance/yiqiying"
+
+// getTracer 未设置TracerProvider时使用no-op tracer
+func (c *Context) getTracer() trace.Tracer {
+	provider := c.TracerProvider
+	if provider == nil {
+		provider = trace.NewNoopTracerProvider()
+	}
+	return provider.Tracer(tracerName)
+}
+
+// getPropagator 未设置Propagator时使用otel全局设置
+func (c *Context) getPropagator() propagation.TextMapPropagator {
+	if c.Propagator == nil {
+		return otel.GetTextMapPropagator()
+	}
+	return c.Propagator
+}
+
+// startSpan 以调用方ctx中的span为父span创建client span
+func (c *Context) startSpan(ctx gocontext.Context, req *Request) (gocontext.Context, trace.Span) {
+	return c.getTracer().Start(ctx, "yiqiying "+req.Service+"."+req.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("yiqiying.service", req.Service),
+			attribute.String("yiqiying.operation", req.Operation),
+			attribute.String("yiqiying.endpoint", req.URL),
+			attribute.String("yiqiying.customer_id", req.param("customerId")),
+			attribute.String("yiqiying.period", req.period()),
+			attribute.String("http.method", req.httpMethod()),
+		),
+	)
+}
+
+// endSpan 记录最后一次请求的结果并结束span
+func (c *Context) endSpan(span trace.Span, a *attempt, err error) {
+	if a != nil {
+		span.SetAttributes(
+			attribute.Int("yiqiying.attempts", a.number),
+			attribute.Int("http.status_code", a.statusCode),
+		)
+		if a.head != nil {
+			span.SetAttributes(attribute.String("yiqiying.result_code", a.head.Code))
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -1,22 +1,24 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
+	gocontext "context"
 	"github.com/gookit/goutil/arrutil"
-	"github.com/yangzhenrui/finance/credential"
 	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying/config"
 	"github.com/yangzhenrui/finance/yiqiying/context"
-	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 )
 
 const (
-	// GetCloseInfoUrl 结账信息接口
-	GetCloseInfoUrl = "https://openapi.17win.com/gateway/openyqdz/alice/closeInfo/getCloseInfo"
+	// GetCloseInfoPath 结账信息接口，相对于网关地址的路径
+	GetCloseInfoPath = "/openyqdz/alice/closeInfo/getCloseInfo"
+
+	// GetCloseInfoUrl 结账信息接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetCloseInfoPath
+	GetCloseInfoUrl = config.DefaultBaseURL + GetCloseInfoPath
 )
 
 type Alice struct {
@@ -27,15 +29,6 @@ func NewAlice(ctx *context.Context) *Alice {
 	return &Alice{ctx}
 }
 
-func (c *Alice) setHeader(signature string, httpRequest *http.Request) {
-	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	httpRequest.Header.Set("version", c.Context.Version)
-	httpRequest.Header.Set("timestamp", strconv.FormatInt(c.Context.Timestamp, 10))
-	httpRequest.Header.Set("appKey", c.Context.Config.AppKey)
-	httpRequest.Header.Set("signature", signature)
-	httpRequest.Header.Set("xReqNonce", c.Context.XReqNonce)
-}
-
 type GetCloseInfoRequest struct {
 	CustomerIds []int64 `json:"customerIds"` // 企业ID，最大支持500个
 }
@@ -54,38 +47,26 @@ type GetCloseInfoList struct {
 }
 
 // GetCloseInfo 结账信息接口
-func (c *Alice) GetCloseInfo(req GetCloseInfoRequest) (result GetCloseInfoResponse, err error) {
+func (c *Alice) GetCloseInfo(req GetCloseInfoRequest) (GetCloseInfoResponse, error) {
+	return c.GetCloseInfoCtx(gocontext.Background(), req)
+}
+
+// GetCloseInfoCtx 结账信息接口，请求随ctx取消或超时
+func (c *Alice) GetCloseInfoCtx(ctx gocontext.Context, req GetCloseInfoRequest) (result GetCloseInfoResponse, err error) {
 	customerIdsOfString := arrutil.AnyToString(req.CustomerIds)
 	customerIdsOfString = strings.ReplaceAll(customerIdsOfString, "[", "")
 	customerIdsOfString = strings.ReplaceAll(customerIdsOfString, "]", "")
 	postData := url.Values{}
 	postData.Add("customerIds", customerIdsOfString)
 
-	httpRequest, err := http.NewRequest("POST", GetCloseInfoUrl, strings.NewReader(postData.Encode()))
-
-	//httpRequest, err := http.NewRequest("POST", GetCloseInfoUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, nil, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("结账信息数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:    "Alice",
+		Operation:  "GetCloseInfo",
+		Method:     http.MethodPost,
+		URL:        GetCloseInfoPath,
+		Idempotent: true,
+		Form:       postData,
+		ErrMsg:     "结账信息数据出错",
+	}, &result)
 	return
 }
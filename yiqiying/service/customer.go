@@ -1,32 +1,53 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"github.com/yangzhenrui/finance/credential"
+	gocontext "context"
 	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying/config"
 	"github.com/yangzhenrui/finance/yiqiying/context"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 )
 
 const (
-	// QueryCustomersUrl 查询客户信息接口
-	QueryCustomersUrl = "https://openapi.17win.com/gateway/openyqdz/manage/customer/queryCustomers"
+	// QueryCustomersPath 查询客户信息接口，相对于网关地址的路径
+	QueryCustomersPath = "/openyqdz/manage/customer/queryCustomers"
 
-	// AddCustomerUrl 新增客户接口
-	AddCustomerUrl = "https://openapi.17win.com/gateway/openyqdz/manage/customer/addCustomer"
+	// QueryCustomersUrl 查询客户信息接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用QueryCustomersPath
+	QueryCustomersUrl = config.DefaultBaseURL + QueryCustomersPath
 
-	// BatchAssignRolesUrl 批量派工接口
-	BatchAssignRolesUrl = "https://openapi.17win.com/gateway/openyqdz/manage/customer/batchAssignRoles"
+	// AddCustomerPath 新增客户接口，相对于网关地址的路径
+	AddCustomerPath = "/openyqdz/manage/customer/addCustomer"
 
-	// UpdateCustomerUrl 更新客户信息接口
-	UpdateCustomerUrl = "https://openapi.17win.com/gateway/openyqdz/manage/customer/updateCustomer"
+	// AddCustomerUrl 新增客户接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用AddCustomerPath
+	AddCustomerUrl = config.DefaultBaseURL + AddCustomerPath
 
-	// UpdateCustomerStatusUrl 更新客户状态接口
-	UpdateCustomerStatusUrl = "https://openapi.17win.com/gateway/openyqdz/manage/customer/updateCustomerStatus"
+	// BatchAssignRolesPath 批量派工接口，相对于网关地址的路径
+	BatchAssignRolesPath = "/openyqdz/manage/customer/batchAssignRoles"
+
+	// BatchAssignRolesUrl 批量派工接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用BatchAssignRolesPath
+	BatchAssignRolesUrl = config.DefaultBaseURL + BatchAssignRolesPath
+
+	// UpdateCustomerPath 更新客户信息接口，相对于网关地址的路径
+	UpdateCustomerPath = "/openyqdz/manage/customer/updateCustomer"
+
+	// UpdateCustomerUrl 更新客户信息接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用UpdateCustomerPath
+	UpdateCustomerUrl = config.DefaultBaseURL + UpdateCustomerPath
+
+	// UpdateCustomerStatusPath 更新客户状态接口，相对于网关地址的路径
+	UpdateCustomerStatusPath = "/openyqdz/manage/customer/updateCustomerStatus"
+
+	// UpdateCustomerStatusUrl 更新客户状态接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用UpdateCustomerStatusPath
+	UpdateCustomerStatusUrl = config.DefaultBaseURL + UpdateCustomerStatusPath
 )
 
 type Customer struct {
@@ -90,43 +111,28 @@ type Pager struct {
 	PageSize    int `json:"pageSize"`
 }
 
-func (c *Customer) setHeader(signature string, httpRequest *http.Request) {
-	httpRequest.Header.Set("Content-Type", "application/json")
-	httpRequest.Header.Set("version", c.Context.Version)
-	httpRequest.Header.Set("timestamp", strconv.FormatInt(c.Context.Timestamp, 10))
-	httpRequest.Header.Set("appKey", c.Context.Config.AppKey)
-	httpRequest.Header.Set("signature", signature)
-	httpRequest.Header.Set("xReqNonce", c.Context.XReqNonce)
-}
-
 // QueryCustomers 查询客户信息
-func (c *Customer) QueryCustomers(req QueryCustomersRequest) (result QueryCustomersResponse, err error) {
-	customersReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(customersReq)
-	httpRequest, err := http.NewRequest("POST", QueryCustomersUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, c.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
+func (c *Customer) QueryCustomers(req QueryCustomersRequest) (QueryCustomersResponse, error) {
+	return c.QueryCustomersCtx(gocontext.Background(), req)
+}
 
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
+// QueryCustomersCtx 查询客户信息，请求随ctx取消或超时
+func (c *Customer) QueryCustomersCtx(ctx gocontext.Context, req QueryCustomersRequest) (result QueryCustomersResponse, err error) {
+	var sign context.SignParams
+	if c.CustomerId != nil {
+		sign = context.SignParams{"customerId": *c.CustomerId}
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("查询三方平台客户信息,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:    "Customer",
+		Operation:  "QueryCustomers",
+		Method:     http.MethodPost,
+		URL:        QueryCustomersPath,
+		Idempotent: true,
+		JSON:       &req,
+		Sign:       sign,
+		ErrMsg:     "查询三方平台客户信息",
+	}, &result)
 	return
 }
 
@@ -143,33 +149,20 @@ type AddCustomerResponse struct {
 }
 
 // AddCustomer 新增客户
-func (c *Customer) AddCustomer(req AddCustomerRequest) (result AddCustomerResponse, err error) {
-	customersReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(customersReq)
-	httpRequest, err := http.NewRequest("POST", AddCustomerUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, nil, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Customer) AddCustomer(req AddCustomerRequest) (AddCustomerResponse, error) {
+	return c.AddCustomerCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("新增客户失败,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+// AddCustomerCtx 新增客户，请求随ctx取消或超时
+func (c *Customer) AddCustomerCtx(ctx gocontext.Context, req AddCustomerRequest) (result AddCustomerResponse, err error) {
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Customer",
+		Operation: "AddCustomer",
+		Method:    http.MethodPost,
+		URL:       AddCustomerPath,
+		JSON:      &req,
+		ErrMsg:    "新增客户失败",
+	}, &result)
 	return
 }
 
@@ -190,33 +183,20 @@ type BatchAssignRolesResponse struct {
 }
 
 // BatchAssignRoles 批量派工
-func (c *Customer) BatchAssignRoles(req BatchAssignRolesRequest) (result BatchAssignRolesResponse, err error) {
-	customersReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(customersReq)
-	httpRequest, err := http.NewRequest("POST", BatchAssignRolesUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, nil, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Customer) BatchAssignRoles(req BatchAssignRolesRequest) (BatchAssignRolesResponse, error) {
+	return c.BatchAssignRolesCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("三方平台批量分配出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+// BatchAssignRolesCtx 批量派工，请求随ctx取消或超时
+func (c *Customer) BatchAssignRolesCtx(ctx gocontext.Context, req BatchAssignRolesRequest) (result BatchAssignRolesResponse, err error) {
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Customer",
+		Operation: "BatchAssignRoles",
+		Method:    http.MethodPost,
+		URL:       BatchAssignRolesPath,
+		JSON:      &req,
+		ErrMsg:    "三方平台批量分配出错",
+	}, &result)
 	return
 }
 
@@ -238,33 +218,20 @@ type UpdateCustomerResponse struct {
 }
 
 // UpdateCustomer 更新客户信息
-func (c *Customer) UpdateCustomer(req UpdateCustomerRequest) (result UpdateCustomerResponse, err error) {
-	customersReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(customersReq)
-	httpRequest, err := http.NewRequest("POST", UpdateCustomerUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, nil, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Customer) UpdateCustomer(req UpdateCustomerRequest) (UpdateCustomerResponse, error) {
+	return c.UpdateCustomerCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("更新客户信息失败,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+// UpdateCustomerCtx 更新客户信息，请求随ctx取消或超时
+func (c *Customer) UpdateCustomerCtx(ctx gocontext.Context, req UpdateCustomerRequest) (result UpdateCustomerResponse, err error) {
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Customer",
+		Operation: "UpdateCustomer",
+		Method:    http.MethodPost,
+		URL:       UpdateCustomerPath,
+		JSON:      &req,
+		ErrMsg:    "更新客户信息失败",
+	}, &result)
 	return
 }
 
@@ -280,32 +247,19 @@ type UpdateCustomerStatusResponse struct {
 }
 
 // UpdateCustomerStatus 更新客户状态
-func (c *Customer) UpdateCustomerStatus(req UpdateCustomerStatusRequest) (result UpdateCustomerStatusResponse, err error) {
-	customersReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(customersReq)
-	httpRequest, err := http.NewRequest("POST", UpdateCustomerStatusUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, nil, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Customer) UpdateCustomerStatus(req UpdateCustomerStatusRequest) (UpdateCustomerStatusResponse, error) {
+	return c.UpdateCustomerStatusCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("更新客户状态失败,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+// UpdateCustomerStatusCtx 更新客户状态，请求随ctx取消或超时
+func (c *Customer) UpdateCustomerStatusCtx(ctx gocontext.Context, req UpdateCustomerStatusRequest) (result UpdateCustomerStatusResponse, err error) {
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Customer",
+		Operation: "UpdateCustomerStatus",
+		Method:    http.MethodPost,
+		URL:       UpdateCustomerStatusPath,
+		JSON:      &req,
+		ErrMsg:    "更新客户状态失败",
+	}, &result)
 	return
 }
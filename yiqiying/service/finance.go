@@ -1,42 +1,78 @@
 package service
 
 import (
-	"bytes"
-	"encoding/json"
-	"fmt"
+	gocontext "context"
 	"github.com/google/go-querystring/query"
-	"github.com/yangzhenrui/finance/credential"
 	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying/config"
 	"github.com/yangzhenrui/finance/yiqiying/context"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 )
 
 const (
-	// QueryAccountBalanceSheetUrl 科目余额表接口
-	QueryAccountBalanceSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/queryAccountBalanceSheet"
+	// QueryAccountBalanceSheetPath 科目余额表接口，相对于网关地址的路径
+	QueryAccountBalanceSheetPath = "/openyqdz/finance/sheetController/queryAccountBalanceSheet"
 
-	// SelectAssetsDebtSheetUrl 资产负债表接口
-	SelectAssetsDebtSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/selectAssetsDebtSheet"
+	// QueryAccountBalanceSheetUrl 科目余额表接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用QueryAccountBalanceSheetPath
+	QueryAccountBalanceSheetUrl = config.DefaultBaseURL + QueryAccountBalanceSheetPath
 
-	// SelectIncomeSheetUrl 利润表接口
-	SelectIncomeSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/selectIncomeSheet"
+	// SelectAssetsDebtSheetPath 资产负债表接口，相对于网关地址的路径
+	SelectAssetsDebtSheetPath = "/openyqdz/finance/sheetController/selectAssetsDebtSheet"
 
-	// GetMonthCashFlowsStatementSheetUrl 现金流量表接口
-	GetMonthCashFlowsStatementSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/getMonthCashFlowsStatement"
+	// SelectAssetsDebtSheetUrl 资产负债表接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用SelectAssetsDebtSheetPath
+	SelectAssetsDebtSheetUrl = config.DefaultBaseURL + SelectAssetsDebtSheetPath
 
-	// SelectQuarterIncomeSheetUrl 利润表季报接口
-	SelectQuarterIncomeSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/selectQuarterIncomeSheet"
+	// SelectIncomeSheetPath 利润表接口，相对于网关地址的路径
+	SelectIncomeSheetPath = "/openyqdz/finance/sheetController/selectIncomeSheet"
 
-	// GetAllYearMonthFinancialPositionStatementSheetUrl 资产负债表全年接口
-	GetAllYearMonthFinancialPositionStatementSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/getAllYearMonthFinancialPositionStatement"
+	// SelectIncomeSheetUrl 利润表接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用SelectIncomeSheetPath
+	SelectIncomeSheetUrl = config.DefaultBaseURL + SelectIncomeSheetPath
 
-	// GetAllYearMonthIncomeStatementSheetUrl 利润表全年接口
-	GetAllYearMonthIncomeStatementSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/getAllYearMonthIncomeStatement"
+	// GetMonthCashFlowsStatementSheetPath 现金流量表接口，相对于网关地址的路径
+	GetMonthCashFlowsStatementSheetPath = "/openyqdz/finance/sheetController/getMonthCashFlowsStatement"
 
-	// GetAllYearMonthCashFlowsStatementSheetUrl 现金流量表全年接口
-	GetAllYearMonthCashFlowsStatementSheetUrl = "https://openapi.17win.com/gateway/openyqdz/finance/sheetController/getAllYearMonthCashFlowsStatement"
+	// GetMonthCashFlowsStatementSheetUrl 现金流量表接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetMonthCashFlowsStatementSheetPath
+	GetMonthCashFlowsStatementSheetUrl = config.DefaultBaseURL + GetMonthCashFlowsStatementSheetPath
+
+	// SelectQuarterIncomeSheetPath 利润表季报接口，相对于网关地址的路径
+	SelectQuarterIncomeSheetPath = "/openyqdz/finance/sheetController/selectQuarterIncomeSheet"
+
+	// SelectQuarterIncomeSheetUrl 利润表季报接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用SelectQuarterIncomeSheetPath
+	SelectQuarterIncomeSheetUrl = config.DefaultBaseURL + SelectQuarterIncomeSheetPath
+
+	// GetAllYearMonthFinancialPositionStatementSheetPath 资产负债表全年接口，相对于网关地址的路径
+	GetAllYearMonthFinancialPositionStatementSheetPath = "/openyqdz/finance/sheetController/getAllYearMonthFinancialPositionStatement"
+
+	// GetAllYearMonthFinancialPositionStatementSheetUrl 资产负债表全年接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetAllYearMonthFinancialPositionStatementSheetPath
+	GetAllYearMonthFinancialPositionStatementSheetUrl = config.DefaultBaseURL + GetAllYearMonthFinancialPositionStatementSheetPath
+
+	// GetAllYearMonthIncomeStatementSheetPath 利润表全年接口，相对于网关地址的路径
+	GetAllYearMonthIncomeStatementSheetPath = "/openyqdz/finance/sheetController/getAllYearMonthIncomeStatement"
+
+	// GetAllYearMonthIncomeStatementSheetUrl 利润表全年接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetAllYearMonthIncomeStatementSheetPath
+	GetAllYearMonthIncomeStatementSheetUrl = config.DefaultBaseURL + GetAllYearMonthIncomeStatementSheetPath
+
+	// GetAllYearMonthCashFlowsStatementSheetPath 现金流量表全年接口，相对于网关地址的路径
+	GetAllYearMonthCashFlowsStatementSheetPath = "/openyqdz/finance/sheetController/getAllYearMonthCashFlowsStatement"
+
+	// GetAllYearMonthCashFlowsStatementSheetUrl 现金流量表全年接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetAllYearMonthCashFlowsStatementSheetPath
+	GetAllYearMonthCashFlowsStatementSheetUrl = config.DefaultBaseURL + GetAllYearMonthCashFlowsStatementSheetPath
 )
 
 type Finance struct {
@@ -47,15 +83,6 @@ func NewFinance(ctx *context.Context) *Finance {
 	return &Finance{ctx}
 }
 
-func (c *Finance) setHeader(signature string, httpRequest *http.Request) {
-	httpRequest.Header.Set("Content-Type", "application/json")
-	httpRequest.Header.Set("version", c.Context.Version)
-	httpRequest.Header.Set("timestamp", strconv.FormatInt(c.Context.Timestamp, 10))
-	httpRequest.Header.Set("appKey", c.Context.Config.AppKey)
-	httpRequest.Header.Set("signature", signature)
-	httpRequest.Header.Set("xReqNonce", c.Context.XReqNonce)
-}
-
 type QueryAccountBalanceSheetRequest struct {
 	CustomerId          string `json:"customerId"`
 	BeginPeriod         string `json:"beginPeriod"`
@@ -130,33 +157,27 @@ type QueryAccountBalanceSheetList struct {
 }
 
 // QueryAccountBalanceSheet 科目余额表接口
-func (c *Finance) QueryAccountBalanceSheet(req QueryAccountBalanceSheetRequest) (result QueryAccountBalanceSheetResponse, err error) {
-	financeReq, err := json.Marshal(&req)
-	reader := bytes.NewReader(financeReq)
-	httpRequest, err := http.NewRequest("POST", QueryAccountBalanceSheetUrl, reader)
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, c.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	c.setHeader(signature, httpRequest)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
-
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("科目余额表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+func (c *Finance) QueryAccountBalanceSheet(req QueryAccountBalanceSheetRequest) (QueryAccountBalanceSheetResponse, error) {
+	return c.QueryAccountBalanceSheetCtx(gocontext.Background(), req)
+}
+
+// QueryAccountBalanceSheetCtx 科目余额表接口，请求随ctx取消或超时
+func (c *Finance) QueryAccountBalanceSheetCtx(ctx gocontext.Context, req QueryAccountBalanceSheetRequest) (result QueryAccountBalanceSheetResponse, err error) {
+	var sign context.SignParams
+	if c.CustomerId != nil {
+		sign = context.SignParams{"customerId": *c.CustomerId}
+	}
+
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:    "Finance",
+		Operation:  "QueryAccountBalanceSheet",
+		Method:     http.MethodPost,
+		URL:        QueryAccountBalanceSheetPath,
+		Idempotent: true,
+		JSON:       &req,
+		Sign:       sign,
+		ErrMsg:     "科目余额表数据出错",
+	}, &result)
 	return
 }
 
@@ -200,46 +221,35 @@ type SelectAssetsDebtSheetList struct {
 }
 
 // SelectAssetsDebtSheet 资产负债表接口
-func (c *Finance) SelectAssetsDebtSheet(req SelectAssetsDebtSheetRequest) (result SelectAssetsDebtSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	hasRf := 1
-	if uriArr.Get("reclassifyFlag") == "" {
-		uriArr.Del("reclassifyFlag")
-		hasRf = 0
-	}
-	url := fmt.Sprintf("%v?%v", SelectAssetsDebtSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, &req.ReclassifyFlag, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
-	if hasRf == 1 {
-		httpRequest.Header.Set("reclassifyFlag", req.ReclassifyFlag)
-	}
-
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Finance) SelectAssetsDebtSheet(req SelectAssetsDebtSheetRequest) (SelectAssetsDebtSheetResponse, error) {
+	return c.SelectAssetsDebtSheetCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
+// SelectAssetsDebtSheetCtx 资产负债表接口，请求随ctx取消或超时
+func (c *Finance) SelectAssetsDebtSheetCtx(ctx gocontext.Context, req SelectAssetsDebtSheetRequest) (result SelectAssetsDebtSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("资产负债表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
+	header := map[string]string{
+		"customerId":    req.CustomerId,
+		"accountPeriod": req.AccountPeriod,
 	}
+	if uriArr.Get("reclassifyFlag") == "" {
+		uriArr.Del("reclassifyFlag")
+	} else {
+		header["reclassifyFlag"] = req.ReclassifyFlag
+	}
+
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "SelectAssetsDebtSheet",
+		URL:       SelectAssetsDebtSheetPath,
+		Query:     uriArr,
+		Header:    header,
+		Sign:      context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId, "reclassifyFlag": req.ReclassifyFlag},
+		ErrMsg:    "资产负债表数据出错",
+	}, &result)
 	return
 }
 
@@ -282,38 +292,29 @@ type SelectIncomeSheetList struct {
 }
 
 // SelectIncomeSheet 利润表接口
-func (c *Finance) SelectIncomeSheet(req SelectIncomeSheetRequest) (result SelectIncomeSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", SelectIncomeSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
+func (c *Finance) SelectIncomeSheet(req SelectIncomeSheetRequest) (SelectIncomeSheetResponse, error) {
+	return c.SelectIncomeSheetCtx(gocontext.Background(), req)
+}
 
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// SelectIncomeSheetCtx 利润表接口，请求随ctx取消或超时
+func (c *Finance) SelectIncomeSheetCtx(ctx gocontext.Context, req SelectIncomeSheetRequest) (result SelectIncomeSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("利润表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "SelectIncomeSheet",
+		URL:       SelectIncomeSheetPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId":    req.CustomerId,
+			"accountPeriod": req.AccountPeriod,
+		},
+		Sign:   context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId},
+		ErrMsg: "利润表数据出错",
+	}, &result)
 	return
 }
 
@@ -340,37 +341,35 @@ type GetMonthCashFlowsStatementSheetList struct {
 }
 
 // GetMonthCashFlowsStatementSheet 现金流量表接口
-func (c *Finance) GetMonthCashFlowsStatementSheet(req GetMonthCashFlowsStatementSheetRequest) (result GetMonthCashFlowsStatementSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", GetMonthCashFlowsStatementSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
+func (c *Finance) GetMonthCashFlowsStatementSheet(req GetMonthCashFlowsStatementSheetRequest) (GetMonthCashFlowsStatementSheetResponse, error) {
+	return c.GetMonthCashFlowsStatementSheetCtx(gocontext.Background(), req)
+}
 
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// GetMonthCashFlowsStatementSheetCtx 现金流量表接口，请求随ctx取消或超时
+func (c *Finance) GetMonthCashFlowsStatementSheetCtx(ctx gocontext.Context, req GetMonthCashFlowsStatementSheetRequest) (result GetMonthCashFlowsStatementSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		err = fmt.Errorf("查无数据")
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("现金流量表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "GetMonthCashFlowsStatementSheet",
+		URL:       GetMonthCashFlowsStatementSheetPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId":    req.CustomerId,
+			"accountPeriod": req.AccountPeriod,
+		},
+		Sign:   context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId},
+		ErrMsg: "现金流量表数据出错",
+	}, &result)
+	// 无数据时网关返回的内容无法解析，保留请求信息并归为资源不存在
+	if apiErr, ok := util.AsAPIError(err); ok && apiErr.Err != nil {
+		notFound := *apiErr
+		notFound.Message = "查无数据"
+		notFound.Kind = util.ErrorKindNotFound
+		err = &notFound
 	}
 	return
 }
@@ -414,38 +413,29 @@ type SelectQuarterIncomeSheetList struct {
 }
 
 // SelectQuarterIncomeSheet 利润表季报接口
-func (c *Finance) SelectQuarterIncomeSheet(req SelectQuarterIncomeSheetRequest) (result SelectQuarterIncomeSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", SelectQuarterIncomeSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
+func (c *Finance) SelectQuarterIncomeSheet(req SelectQuarterIncomeSheetRequest) (SelectQuarterIncomeSheetResponse, error) {
+	return c.SelectQuarterIncomeSheetCtx(gocontext.Background(), req)
+}
 
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// SelectQuarterIncomeSheetCtx 利润表季报接口，请求随ctx取消或超时
+func (c *Finance) SelectQuarterIncomeSheetCtx(ctx gocontext.Context, req SelectQuarterIncomeSheetRequest) (result SelectQuarterIncomeSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("利润表季报表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "SelectQuarterIncomeSheet",
+		URL:       SelectQuarterIncomeSheetPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId":    req.CustomerId,
+			"accountPeriod": req.AccountPeriod,
+		},
+		Sign:   context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId},
+		ErrMsg: "利润表季报表数据出错",
+	}, &result)
 	return
 }
 
@@ -489,45 +479,35 @@ type GetAllYearMonthFinancialPositionStatementSheetList struct {
 }
 
 // GetAllYearMonthFinancialPositionStatementSheet 资产负债表全年接口
-func (c *Finance) GetAllYearMonthFinancialPositionStatementSheet(req GetAllYearMonthFinancialPositionStatementSheetRequest) (result GetAllYearMonthFinancialPositionStatementSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	hasRf := 1
-	if uriArr.Get("reclassifyFlag") == "" {
-		uriArr.Del("reclassifyFlag")
-		hasRf = 0
-	}
-	url := fmt.Sprintf("%v?%v", GetAllYearMonthFinancialPositionStatementSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, &req.ReclassifyFlag, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
-	if hasRf == 1 {
-		httpRequest.Header.Set("reclassifyFlag", req.ReclassifyFlag)
-	}
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
-	}
+func (c *Finance) GetAllYearMonthFinancialPositionStatementSheet(req GetAllYearMonthFinancialPositionStatementSheetRequest) (GetAllYearMonthFinancialPositionStatementSheetResponse, error) {
+	return c.GetAllYearMonthFinancialPositionStatementSheetCtx(gocontext.Background(), req)
+}
 
-	err = json.Unmarshal(body, &result)
+// GetAllYearMonthFinancialPositionStatementSheetCtx 资产负债表全年接口，请求随ctx取消或超时
+func (c *Finance) GetAllYearMonthFinancialPositionStatementSheetCtx(ctx gocontext.Context, req GetAllYearMonthFinancialPositionStatementSheetRequest) (result GetAllYearMonthFinancialPositionStatementSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("资产负债表全年表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
+	header := map[string]string{
+		"customerId":    req.CustomerId,
+		"accountPeriod": req.AccountPeriod,
 	}
+	if uriArr.Get("reclassifyFlag") == "" {
+		uriArr.Del("reclassifyFlag")
+	} else {
+		header["reclassifyFlag"] = req.ReclassifyFlag
+	}
+
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "GetAllYearMonthFinancialPositionStatementSheet",
+		URL:       GetAllYearMonthFinancialPositionStatementSheetPath,
+		Query:     uriArr,
+		Header:    header,
+		Sign:      context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId, "reclassifyFlag": req.ReclassifyFlag},
+		ErrMsg:    "资产负债表全年表数据出错",
+	}, &result)
 	return
 }
 
@@ -570,38 +550,29 @@ type GetAllYearMonthIncomeStatementSheetList struct {
 }
 
 // GetAllYearMonthIncomeStatementSheet 利润表全年接口
-func (c *Finance) GetAllYearMonthIncomeStatementSheet(req GetAllYearMonthIncomeStatementSheetRequest) (result GetAllYearMonthIncomeStatementSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", GetAllYearMonthIncomeStatementSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
+func (c *Finance) GetAllYearMonthIncomeStatementSheet(req GetAllYearMonthIncomeStatementSheetRequest) (GetAllYearMonthIncomeStatementSheetResponse, error) {
+	return c.GetAllYearMonthIncomeStatementSheetCtx(gocontext.Background(), req)
+}
 
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// GetAllYearMonthIncomeStatementSheetCtx 利润表全年接口，请求随ctx取消或超时
+func (c *Finance) GetAllYearMonthIncomeStatementSheetCtx(ctx gocontext.Context, req GetAllYearMonthIncomeStatementSheetRequest) (result GetAllYearMonthIncomeStatementSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("利润表全年表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "GetAllYearMonthIncomeStatementSheet",
+		URL:       GetAllYearMonthIncomeStatementSheetPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId":    req.CustomerId,
+			"accountPeriod": req.AccountPeriod,
+		},
+		Sign:   context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId},
+		ErrMsg: "利润表全年表数据出错",
+	}, &result)
 	return
 }
 
@@ -628,37 +599,28 @@ type GetAllYearMonthCashFlowsStatementSheetList struct {
 }
 
 // GetAllYearMonthCashFlowsStatementSheet 现金流量表全年接口
-func (c *Finance) GetAllYearMonthCashFlowsStatementSheet(req GetAllYearMonthCashFlowsStatementSheetRequest) (result GetAllYearMonthCashFlowsStatementSheetResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", GetAllYearMonthCashFlowsStatementSheetUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(&req.AccountPeriod, c.AppKey, c.AppSecret, &req.CustomerId, nil, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("accountPeriod", req.AccountPeriod)
+func (c *Finance) GetAllYearMonthCashFlowsStatementSheet(req GetAllYearMonthCashFlowsStatementSheetRequest) (GetAllYearMonthCashFlowsStatementSheetResponse, error) {
+	return c.GetAllYearMonthCashFlowsStatementSheetCtx(gocontext.Background(), req)
+}
 
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// GetAllYearMonthCashFlowsStatementSheetCtx 现金流量表全年接口，请求随ctx取消或超时
+func (c *Finance) GetAllYearMonthCashFlowsStatementSheetCtx(ctx gocontext.Context, req GetAllYearMonthCashFlowsStatementSheetRequest) (result GetAllYearMonthCashFlowsStatementSheetResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("现金流量表全年表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Finance",
+		Operation: "GetAllYearMonthCashFlowsStatementSheet",
+		URL:       GetAllYearMonthCashFlowsStatementSheetPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId":    req.CustomerId,
+			"accountPeriod": req.AccountPeriod,
+		},
+		Sign:   context.SignParams{"accountPeriod": req.AccountPeriod, "customerId": req.CustomerId},
+		ErrMsg: "现金流量表全年表数据出错",
+	}, &result)
 	return
 }
@@ -0,0 +1,202 @@
+package service_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yangzhenrui/finance/cache"
+	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/ratelimit"
+	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying"
+	"github.com/yangzhenrui/finance/yiqiying/config"
+	"github.com/yangzhenrui/finance/yiqiying/context"
+	"github.com/yangzhenrui/finance/yiqiying/service"
+)
+
+func newTestYiQiYing(handler http.HandlerFunc) (*yiqiying.YiQiYing, func()) {
+	server := httptest.NewServer(handler)
+	yqy := yiqiying.NewYiQiYing(&config.Config{
+		AppKey:    "appKey",
+		AppSecret: "appSecret",
+		Cache:     cache.NewMemory(),
+		BaseURL:   server.URL + "/gateway/",
+	})
+	return yqy, server.Close
+}
+
+func TestDeprecatedUrl(t *testing.T) {
+	assert.Equal(t, "https://openapi.17win.com/gateway/openyqdz/manage/customer/queryCustomers", service.QueryCustomersUrl)
+	assert.Equal(t, "https://openapi.17win.com/gateway/openyqdz/tax/info/getTaxList", service.GetTaxListUrl)
+}
+
+func TestGetTaxList(t *testing.T) {
+	yqy, closeServer := newTestYiQiYing(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/gateway"+service.GetTaxListPath, r.URL.Path)
+		assert.Equal(t, "c1", r.URL.Query().Get("customerId"))
+		assert.Equal(t, "202205", r.URL.Query().Get("period"))
+		assert.False(t, r.URL.Query().Has("taxCode"))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":[{"taxName":"增值税","taxCode":"vat"}]}`))
+	})
+	defer closeServer()
+
+	result, err := yqy.GetTax().GetTaxList(service.GetTaxListRequest{CustomerId: "c1", Period: "202205"})
+	assert.Nil(t, err)
+	assert.Len(t, result.Body, 1)
+	assert.Equal(t, "vat", result.Body[0].TaxCode)
+}
+
+func TestQueryCustomers(t *testing.T) {
+	yqy, closeServer := newTestYiQiYing(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/gateway"+service.QueryCustomersPath, r.URL.Path)
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":{"total":1,"customerList":[{"customerId":"c1"}]}}`))
+	})
+	defer closeServer()
+
+	result, err := yqy.GetCustomers().QueryCustomers(service.QueryCustomersRequest{PageNo: 1, PageSize: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, result.QueryCustomersResponseBody.Total)
+	assert.Equal(t, "c1", result.QueryCustomersResponseBody.CustomerList[0].CustomerId)
+}
+
+func TestGetMonthCashFlowsStatementSheetNoData(t *testing.T) {
+	yqy, closeServer := newTestYiQiYing(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html>no data</html>`))
+	})
+	defer closeServer()
+
+	_, err := yqy.GetFinance().GetMonthCashFlowsStatementSheet(service.GetMonthCashFlowsStatementSheetRequest{CustomerId: "c1", AccountPeriod: "202205"})
+	assert.True(t, util.IsNotFound(err))
+	apiErr, ok := util.AsAPIError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "查无数据", apiErr.Message)
+	assert.Equal(t, http.StatusOK, apiErr.StatusCode)
+	assert.NotEmpty(t, apiErr.URL)
+	assert.NotEmpty(t, apiErr.XReqNonce)
+}
+
+func TestServicesConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	nonces := map[string]bool{}
+	yqy, closeServer := newTestYiQiYing(func(w http.ResponseWriter, r *http.Request) {
+		nonce := r.Header.Get("xReqNonce")
+		mu.Lock()
+		reused := nonces[nonce]
+		nonces[nonce] = true
+		mu.Unlock()
+		assert.False(t, reused)
+
+		// GET接口的签名参数即query参数，按收到的请求重新计算签名
+		if r.Method == http.MethodGet {
+			params := map[string]string{}
+			for k := range r.URL.Query() {
+				params[k] = r.URL.Query().Get(k)
+			}
+			timestamp, _ := strconv.ParseInt(r.Header.Get("timestamp"), 10, 64)
+			expected, _ := credential.NewSignature("appKey", "appSecret", timestamp, r.Header.Get("version"), nonce, params).GetSignature()
+			if r.Header.Get("signature") != expected {
+				_, _ = w.Write([]byte(`{"head":{"code":"10000001","msg":"签名错误","status":"N"}}`))
+				return
+			}
+		}
+		if r.URL.Path == "/gateway"+service.GetTaxListPath {
+			_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":[{"taxCode":"` + r.URL.Query().Get("customerId") + `"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"}}`))
+	})
+	defer closeServer()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		i := i
+		customerID := strconv.Itoa(i)
+		period := "2022" + strconv.Itoa(i%3+10)
+		calls := []func() error{
+			func() error {
+				_, err := yqy.GetCustomers().QueryCustomers(service.QueryCustomersRequest{PageNo: i + 1, PageSize: 10})
+				return err
+			},
+			func() error {
+				result, err := yqy.GetTax().GetTaxList(service.GetTaxListRequest{CustomerId: customerID, Period: period})
+				if err == nil {
+					assert.Equal(t, customerID, result.Body[0].TaxCode)
+				}
+				return err
+			},
+			func() error {
+				_, err := yqy.GetFinance().SelectAssetsDebtSheet(service.SelectAssetsDebtSheetRequest{CustomerId: customerID, AccountPeriod: period})
+				return err
+			},
+			func() error {
+				_, err := yqy.GetAlice().GetCloseInfo(service.GetCloseInfoRequest{CustomerIds: []int64{int64(i)}})
+				return err
+			},
+		}
+		for _, call := range calls {
+			wg.Add(1)
+			go func(call func() error) {
+				defer wg.Done()
+				assert.Nil(t, call())
+			}(call)
+		}
+	}
+	wg.Wait()
+	assert.Len(t, nonces, 80)
+}
+
+func TestNewYiQiYingRateLimits(t *testing.T) {
+	cfg := &config.Config{
+		AppKey:     "appKey",
+		AppSecret:  "appSecret",
+		Cache:      cache.NewMemory(),
+		RateLimits: map[string]ratelimit.Limit{"appKey": {QPS: 10, Burst: 1}},
+	}
+	first := yiqiying.NewYiQiYing(cfg)
+	second := yiqiying.NewYiQiYing(cfg)
+	// 不修改调用方的配置，每个实例各自持有限流
+	assert.Nil(t, cfg.RateLimit)
+	assert.NotNil(t, first.GetContext().Limiter)
+	assert.NotSame(t, first.GetContext().Limiter, second.GetContext().Limiter)
+}
+
+func TestInterceptors(t *testing.T) {
+	yqy, closeServer := newTestYiQiYing(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "audit", r.Header.Get("X-Trace"))
+		_, _ = w.Write([]byte(`{"head":{"code":"00000000","status":"Y"},"body":[]}`))
+	})
+	defer closeServer()
+
+	var codes []string
+	yqy.Use(context.InterceptorFuncs{
+		Before: func(req *http.Request) error {
+			assert.NotEmpty(t, req.Header.Get("signature"))
+			req.Header.Set("X-Trace", "audit")
+			return nil
+		},
+		After: func(req *http.Request, statusCode int, body []byte, head *util.CommonError) error {
+			assert.Equal(t, http.StatusOK, statusCode)
+			assert.NotEmpty(t, body)
+			codes = append(codes, head.Code)
+			return nil
+		},
+	})
+	_, err := yqy.GetTax().GetReport(service.GetReportRequest{CustomerId: "c1", Period: "202205", TaxCode: "vat"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"00000000"}, codes)
+
+	injected := errors.New("injected fault")
+	yqy.Use(context.InterceptorFuncs{
+		After: func(req *http.Request, statusCode int, body []byte, head *util.CommonError) error {
+			return injected
+		},
+	})
+	_, err = yqy.GetCustomers().AddCustomer(service.AddCustomerRequest{CustomerName: "test"})
+	assert.Equal(t, injected, err)
+}
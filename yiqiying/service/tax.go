@@ -1,26 +1,37 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
+	gocontext "context"
 	"github.com/google/go-querystring/query"
-	"github.com/yangzhenrui/finance/credential"
 	"github.com/yangzhenrui/finance/util"
+	"github.com/yangzhenrui/finance/yiqiying/config"
 	"github.com/yangzhenrui/finance/yiqiying/context"
-	"io/ioutil"
-	"net/http"
-	"strconv"
 )
 
 const (
-	// GetTaxListUrl 查询税种信息接口
-	GetTaxListUrl = "https://openapi.17win.com/gateway/openyqdz/tax/info/getTaxList"
+	// GetTaxListPath 查询税种信息接口，相对于网关地址的路径
+	GetTaxListPath = "/openyqdz/tax/info/getTaxList"
 
-	// GetReportUrl 查询税种报表数据接口
-	GetReportUrl = "https://openapi.17win.com/gateway/openyqdz/tax/info/getReport"
+	// GetTaxListUrl 查询税种信息接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetTaxListPath
+	GetTaxListUrl = config.DefaultBaseURL + GetTaxListPath
 
-	// GetTaxIdentificationUrl 查询税费种认定信息
-	GetTaxIdentificationUrl = "https://openapi.17win.com/gateway/openyqdz/tax/info/getTaxIdentification"
+	// GetReportPath 查询税种报表数据接口，相对于网关地址的路径
+	GetReportPath = "/openyqdz/tax/info/getReport"
+
+	// GetReportUrl 查询税种报表数据接口的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetReportPath
+	GetReportUrl = config.DefaultBaseURL + GetReportPath
+
+	// GetTaxIdentificationPath 查询税费种认定信息，相对于网关地址的路径
+	GetTaxIdentificationPath = "/openyqdz/tax/info/getTaxIdentification"
+
+	// GetTaxIdentificationUrl 查询税费种认定信息的完整地址
+	//
+	// Deprecated: 固定指向config.DefaultBaseURL，不随Config.BaseURL变化，请使用GetTaxIdentificationPath
+	GetTaxIdentificationUrl = config.DefaultBaseURL + GetTaxIdentificationPath
 )
 
 type Tax struct {
@@ -31,15 +42,6 @@ func NewTax(ctx *context.Context) *Tax {
 	return &Tax{ctx}
 }
 
-func (c *Tax) setHeader(signature string, httpRequest *http.Request) {
-	httpRequest.Header.Set("Content-Type", "application/json")
-	httpRequest.Header.Set("version", c.Context.Version)
-	httpRequest.Header.Set("timestamp", strconv.FormatInt(c.Context.Timestamp, 10))
-	httpRequest.Header.Set("appKey", c.Context.Config.AppKey)
-	httpRequest.Header.Set("signature", signature)
-	httpRequest.Header.Set("xReqNonce", c.Context.XReqNonce)
-}
-
 type GetTaxListRequest struct {
 	CustomerId string `json:"customerId" form:"customerId" url:"customerId"`
 	Period     string `json:"period" form:"period" url:"period"`
@@ -90,50 +92,38 @@ type Fjss struct {
 }
 
 // GetTaxList 查询税种信息接口
-func (c *Tax) GetTaxList(req GetTaxListRequest) (result GetTaxListResponse, err error) {
-	uriArr, _ := query.Values(req)
-	hasTc := 1
-	if uriArr.Get("taxCode") == "" {
-		uriArr.Del("taxCode")
-		hasTc = 0
-	}
-	url := fmt.Sprintf("%v?%v", GetTaxListUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
+func (c *Tax) GetTaxList(req GetTaxListRequest) (GetTaxListResponse, error) {
+	return c.GetTaxListCtx(gocontext.Background(), req)
+}
 
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, &req.CustomerId, &req.Period, nil, &req.TaxCode, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
+// GetTaxListCtx 查询税种信息接口，请求随ctx取消或超时
+func (c *Tax) GetTaxListCtx(ctx gocontext.Context, req GetTaxListRequest) (result GetTaxListResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
-
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
+	header := map[string]string{}
 	if c.CustomerId != nil {
-		httpRequest.Header.Set("customerId", req.CustomerId)
+		header["customerId"] = req.CustomerId
 	}
 	if c.Period != nil {
-		httpRequest.Header.Set("period", req.Period)
-	}
-	if hasTc == 1 {
-		httpRequest.Header.Set("taxCode", req.TaxCode)
+		header["period"] = req.Period
 	}
-
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return
+	if uriArr.Get("taxCode") == "" {
+		uriArr.Del("taxCode")
+	} else {
+		header["taxCode"] = req.TaxCode
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("查询税种信息出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Tax",
+		Operation: "GetTaxList",
+		URL:       GetTaxListPath,
+		Query:     uriArr,
+		Header:    header,
+		Sign:      context.SignParams{"customerId": req.CustomerId, "period": req.Period, "taxCode": req.TaxCode},
+		ErrMsg:    "查询税种信息出错",
+	}, &result)
 	return
 }
 
@@ -212,38 +202,30 @@ type OtherParamMap struct {
 }
 
 // GetReport 查询税种报表数据接口
-func (c *Tax) GetReport(req GetReportRequest) (result GetReportResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", GetReportUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, &req.CustomerId, &req.Period, nil, &req.TaxCode, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
+func (c *Tax) GetReport(req GetReportRequest) (GetReportResponse, error) {
+	return c.GetReportCtx(gocontext.Background(), req)
+}
 
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("customerId", req.CustomerId)
-	httpRequest.Header.Set("period", req.Period)
-	httpRequest.Header.Set("taxCode", req.TaxCode)
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// GetReportCtx 查询税种报表数据接口，请求随ctx取消或超时
+func (c *Tax) GetReportCtx(ctx gocontext.Context, req GetReportRequest) (result GetReportResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("查询税种报表数据出错,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:   "Tax",
+		Operation: "GetReport",
+		URL:       GetReportPath,
+		Query:     uriArr,
+		Header: map[string]string{
+			"customerId": req.CustomerId,
+			"period":     req.Period,
+			"taxCode":    req.TaxCode,
+		},
+		Sign:   context.SignParams{"customerId": req.CustomerId, "period": req.Period, "taxCode": req.TaxCode},
+		ErrMsg: "查询税种报表数据出错",
+	}, &result)
 	return
 }
 
@@ -298,35 +280,25 @@ type TaxableCertificateConfig struct {
 }
 
 // GetTaxIdentification 查询税费种认定信息
-func (c *Tax) GetTaxIdentification(req GetTaxIdentificationRequest) (result GetTaxIdentificationResponse, err error) {
-	uriArr, _ := query.Values(req)
-	url := fmt.Sprintf("%v?%v", GetTaxIdentificationUrl, uriArr.Encode())
-	httpRequest, err := http.NewRequest("GET", url, nil)
-
-	c.SignatureHandle = credential.NewDefaultSignature(nil, c.AppKey, c.AppSecret, &req.CustomerId, &req.Period, nil, nil, c.Timestamp, c.Version, c.XReqNonce, credential.CacheKeyYiQiYingPrefix, c.Cache)
-	signature, err := c.GetSignature()
-	if err != nil {
-		return
-	}
+func (c *Tax) GetTaxIdentification(req GetTaxIdentificationRequest) (GetTaxIdentificationResponse, error) {
+	return c.GetTaxIdentificationCtx(gocontext.Background(), req)
+}
 
-	// 请求头设置
-	c.setHeader(signature, httpRequest)
-	httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	client := &http.Client{}
-	response, err := client.Do(httpRequest)
-	defer response.Body.Close()
-	body, err := ioutil.ReadAll(response.Body)
+// GetTaxIdentificationCtx 查询税费种认定信息，请求随ctx取消或超时
+func (c *Tax) GetTaxIdentificationCtx(ctx gocontext.Context, req GetTaxIdentificationRequest) (result GetTaxIdentificationResponse, err error) {
+	uriArr, err := query.Values(req)
 	if err != nil {
 		return
 	}
 
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return
-	}
-	if result.Head.Status != "Y" || result.Head.Code != "00000000" {
-		err = fmt.Errorf("查询税费种认定信息,%v,%v 出错代码为(%v)", result.Head.Msg, result.Head.Description, result.Head.Code)
-		return
-	}
+	err = c.ExecuteCtx(ctx, &context.Request{
+		Service:     "Tax",
+		Operation:   "GetTaxIdentification",
+		URL:         GetTaxIdentificationPath,
+		Query:       uriArr,
+		ContentType: context.ContentTypeForm,
+		Sign:        context.SignParams{"customerId": req.CustomerId, "period": req.Period},
+		ErrMsg:      "查询税费种认定信息",
+	}, &result)
 	return
 }
@@ -1,13 +1,13 @@
 package yiqiying
 
 import (
-	uuid "github.com/satori/go.uuid"
+	"time"
+
 	"github.com/yangzhenrui/finance/credential"
+	"github.com/yangzhenrui/finance/logger"
 	"github.com/yangzhenrui/finance/yiqiying/config"
 	"github.com/yangzhenrui/finance/yiqiying/context"
 	service2 "github.com/yangzhenrui/finance/yiqiying/service"
-	"strings"
-	"time"
 )
 
 // YiQiYing 亿企赢
@@ -17,24 +17,60 @@ type YiQiYing struct {
 
 // NewYiQiYing 实例化亿企赢API
 func NewYiQiYing(cfg *config.Config) *YiQiYing {
-	timestamp := time.Now().UnixNano() / 1e6
-	xReqNonce := strings.Replace(uuid.NewV4().String(), "-", "", -1)
 	version := "1.0.0"
-	//signatureHandle := credential.NewDefaultSignature(cfg.AppKey, cfg.AppSecret, timestamp, version, xReqNonce, credential.CacheKeyYiQiYingPrefix, cfg.Cache)
 
 	ctx := &context.Context{
-		Config: cfg,
-		//SignatureHandle: signatureHandle,
-		Timestamp: timestamp,
-		XReqNonce: xReqNonce,
-		Version:   version,
+		Config:     cfg,
+		HTTPClient: cfg.NewHTTPClient(),
+		Rotator:    credential.NewRotator(),
+		Limiter:    cfg.NewRateLimiter(),
+		Version:    version,
 	}
 	return &YiQiYing{ctx}
 }
 
-// SetSignatureHandle 自定义signature获取方式
-func (yqy *YiQiYing) SetSignatureHandle(signatureHandle credential.SignatureHandle) {
-	yqy.ctx.SignatureHandle = signatureHandle
+// SetClock 自定义生成请求timestamp的时钟，主要用于测试
+func (yqy *YiQiYing) SetClock(clock context.Clock) {
+	yqy.ctx.Clock = clock
+}
+
+// SetNonceSource 自定义xReqNonce的生成方式，主要用于测试
+func (yqy *YiQiYing) SetNonceSource(nonceSource context.NonceSource) {
+	yqy.ctx.NonceSource = nonceSource
+}
+
+// Use 添加请求拦截器，所有服务的请求都会依次经过，需在发起请求前设置
+func (yqy *YiQiYing) Use(interceptors ...context.Interceptor) {
+	yqy.ctx.Interceptors = append(yqy.ctx.Interceptors, interceptors...)
+}
+
+// SetLogger 设置请求日志，需在发起请求前设置
+func (yqy *YiQiYing) SetLogger(l logger.Logger) {
+	yqy.ctx.Logger = l
+}
+
+// SetCredentialProvider 设置多租户凭证，请求ctx通过credential.WithTenant指定租户，需在发起请求前设置
+func (yqy *YiQiYing) SetCredentialProvider(provider credential.Provider) {
+	yqy.ctx.Credentials = provider
+}
+
+// OnSecretRotation 监听appSecret轮换事件，可用于告警
+func (yqy *YiQiYing) OnSecretRotation(listener credential.RotationListener) {
+	yqy.ctx.Rotator.Subscribe(listener)
+}
+
+// EnableClockSkew 开启时钟偏差校正，默认关闭，需在发起请求前设置
+// 根据响应的Date头或head.time测量偏差，签名的timestamp加上最近样本的中位数，超过context.DefaultMaxSkew的样本丢弃
+func (yqy *YiQiYing) EnableClockSkew() {
+	yqy.ctx.Skew = context.NewClockSkew()
+}
+
+// ClockSkew 测得的网关与本地时钟偏差，网关较快时为正，可用于监控，未开启校正时为0
+func (yqy *YiQiYing) ClockSkew() time.Duration {
+	if yqy.ctx.Skew == nil {
+		return 0
+	}
+	return yqy.ctx.Skew.Offset()
 }
 
 // GetContext get Context